@@ -0,0 +1,107 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+)
+
+//maxLineSize is the longest line that ValidateLines accepts.
+const maxLineSize = 64 * 1024 * 1024
+
+//LineResult is the result of validating a single line of newline delimited json.
+type LineResult struct {
+	//Line is the line number, starting at 1.
+	Line  int
+	Valid bool
+	//Failures explain why a line that could be parsed is not valid.
+	Failures []*Failure
+	//Err is set when the line could not be parsed or interpreted.
+	Err error
+}
+
+type lineJob struct {
+	line   int
+	data   []byte
+	result chan *LineResult
+}
+
+//ValidateLines reads newline delimited json from r and validates each line using the given number of workers.
+//If workers is less than one, runtime.NumCPU() workers are used.
+//Empty lines are skipped, but still counted.
+//The results are passed to emit in the order of the input.
+//The returned error is only set if r could not be read.
+func (this *Validator) ValidateLines(r io.Reader, workers int, emit func(*LineResult)) error {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan *lineJob, workers)
+	order := make(chan *lineJob, 2*workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.result <- this.validateLine(job.line, job.data)
+			}
+		}()
+	}
+	var readErr error
+	go func() {
+		defer close(order)
+		defer close(jobs)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, maxLineSize)
+		line := 0
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+			job := &lineJob{
+				line:   line,
+				data:   append([]byte(nil), data...),
+				result: make(chan *LineResult, 1),
+			}
+			order <- job
+			jobs <- job
+		}
+		readErr = scanner.Err()
+	}()
+	for job := range order {
+		emit(<-job.result)
+	}
+	wg.Wait()
+	return readErr
+}
+
+func (this *Validator) validateLine(line int, data []byte) *LineResult {
+	res := &LineResult{Line: line}
+	err := this.Validate(data)
+	if err == nil {
+		res.Valid = true
+	} else if verr, ok := err.(*ValidationError); ok {
+		res.Failures = verr.Failures
+	} else {
+		res.Err = err
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestValidateLines(t *testing.T) {
+	v, err := Compile([]byte(`{"type": "integer", "maximum": 100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	for i := 0; i < 200; i++ {
+		if i == 50 {
+			buf.WriteString("\n")
+			continue
+		}
+		if i == 150 {
+			buf.WriteString("{\n")
+			continue
+		}
+		fmt.Fprintf(buf, "%d\n", i)
+	}
+	results := []*LineResult{}
+	if err := v.ValidateLines(buf, 4, func(res *LineResult) {
+		results = append(results, res)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 199 {
+		t.Fatalf("expected 199 results, but got %d", len(results))
+	}
+	prev := 0
+	for _, res := range results {
+		if res.Line <= prev {
+			t.Fatalf("line %d emitted after line %d", res.Line, prev)
+		}
+		prev = res.Line
+		i := res.Line - 1
+		switch {
+		case i == 150:
+			if res.Err == nil {
+				t.Errorf("line %d: expected a parse error", res.Line)
+			}
+		case i <= 100:
+			if !res.Valid {
+				t.Errorf("line %d: expected valid, but got %v %v", res.Line, res.Failures, res.Err)
+			}
+		default:
+			if res.Valid || len(res.Failures) != 1 || res.Failures[0].Keyword != "maximum" {
+				t.Errorf("line %d: expected maximum failure, but got %v %v", res.Line, res.Failures, res.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/katydid/katydid/relapse/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+//compiledVersion changes whenever the translation changes, so that grammars translated by an older version are stale.
+const compiledVersion = 1
+
+//HashSchema returns the content hash that a CompiledSchema of the schema, translated with the draft, is keyed by.
+func HashSchema(schema []byte, draft Draft) string {
+	h := sha256.New()
+	h.Write([]byte("jsonschema/" + strconv.Itoa(compiledVersion) + "/" + draft.String() + "\n"))
+	h.Write(schema)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//CompiledSchema is a translated schema that can be serialized, with MarshalBinary or encoding/json,
+//and loaded again without translating the schema.
+type CompiledSchema struct {
+	//Hash is the HashSchema of the schema that was compiled.
+	Hash  string `json:"hash"`
+	Draft Draft  `json:"draft"`
+	//Schema is the parsed schema, which is needed to report validation failures.
+	Schema json.RawMessage `json:"schema"`
+	//Grammar is the marshaled relapse grammar.
+	Grammar []byte `json:"grammar"`
+}
+
+//CompileToCache compiles the schema into a CompiledSchema that can be stored.
+func CompileToCache(schema []byte, opts ...Option) (*CompiledSchema, error) {
+	o := newOptions(opts)
+	v, err := Compile(schema, opts...)
+	if err != nil {
+		return nil, err
+	}
+	g, err := v.grammar.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := json.Marshal(v.schema)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledSchema{
+		Hash:    HashSchema(schema, o.draft),
+		Draft:   o.draft,
+		Schema:  parsed,
+		Grammar: g,
+	}, nil
+}
+
+//Stale returns whether the CompiledSchema was not compiled from this schema,
+//with this draft and this version of the translation.
+func (this *CompiledSchema) Stale(schema []byte) bool {
+	return this.Hash != HashSchema(schema, this.Draft)
+}
+
+//Validator returns a Validator for the compiled schema without translating the schema.
+//The schema is only parsed once it is needed to report validation failures.
+//Options, like WithBaseURI, that do not change the translation are applied to the Validator.
+func (this *CompiledSchema) Validator(opts ...Option) (*Validator, error) {
+	o := newOptions(opts)
+	g := &relapse.Grammar{}
+	if err := g.Unmarshal(this.Grammar); err != nil {
+		return nil, err
+	}
+	v := &Validator{
+		encodedSchema: this.Schema,
+		draft:         this.Draft,
+		grammar:       g,
+		encoded:       this.Grammar,
+		baseURI:       o.baseURI,
+	}
+	v.putMachine(newMachine(g))
+	return v, nil
+}
+
+//gobSchema does not have the MarshalBinary method, which gob would call instead of encoding the fields.
+type gobSchema CompiledSchema
+
+func (this *CompiledSchema) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode((*gobSchema)(this)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (this *CompiledSchema) UnmarshalBinary(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode((*gobSchema)(this))
+}
+
+//SchemaCache stores CompiledSchemas as files in a directory, named by their hash.
+//A SchemaCache is safe for concurrent use, also by multiple processes.
+type SchemaCache struct {
+	dir string
+	//compile is CompileToCache, except in tests that count the translations.
+	compile func(schema []byte, opts ...Option) (*CompiledSchema, error)
+}
+
+//NewSchemaCache returns a cache that stores its files in the directory, which is created if it does not exist.
+func NewSchemaCache(dir string) (*SchemaCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &SchemaCache{dir: dir, compile: CompileToCache}, nil
+}
+
+//Compile returns a Validator for the schema, which is only translated if it is not in the cache yet.
+//A cached file that cannot be read, or that is stale, is replaced.
+func (this *SchemaCache) Compile(schema []byte, opts ...Option) (*Validator, error) {
+	o := newOptions(opts)
+	filename := filepath.Join(this.dir, HashSchema(schema, o.draft)+".grammar")
+	if data, err := ioutil.ReadFile(filename); err == nil {
+		c := &CompiledSchema{}
+		if err := c.UnmarshalBinary(data); err == nil && !c.Stale(schema) {
+			if v, err := c.Validator(opts...); err == nil {
+				return v, nil
+			}
+		}
+	}
+	c, err := this.compile(schema, opts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	//write to a temporary file first, so that other processes never read a partial file.
+	tmp, err := ioutil.TempFile(this.dir, ".grammar")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("caching %s: %v", filename, err)
+	}
+	return c.Validator(opts...)
+}
@@ -0,0 +1,203 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var cachedSchema = []byte(`{"type": "object", "properties": {"a": {"type": "integer", "maximum": 3}}, "required": ["a"]}`)
+
+func TestHashSchema(t *testing.T) {
+	h := HashSchema(cachedSchema, Draft4)
+	if h != HashSchema(cachedSchema, Draft4) {
+		t.Fatalf("expected the same hash for the same schema")
+	}
+	if h == HashSchema(cachedSchema, Draft(6)) {
+		t.Fatalf("expected a different hash for a different draft")
+	}
+	if h == HashSchema([]byte(`{"type": "object"}`), Draft4) {
+		t.Fatalf("expected a different hash for a different schema")
+	}
+}
+
+func TestCompiledSchemaSerialize(t *testing.T) {
+	c, err := CompileToCache(cachedSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Stale(cachedSchema) {
+		t.Fatalf("expected the compiled schema to be fresh")
+	}
+	if !c.Stale([]byte(`{"type": "object"}`)) {
+		t.Fatalf("expected the compiled schema to be stale for another schema")
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := &CompiledSchema{}
+	if err := binary.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	data, err = json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJson := &CompiledSchema{}
+	if err := json.Unmarshal(data, fromJson); err != nil {
+		t.Fatal(err)
+	}
+	for _, loaded := range []*CompiledSchema{binary, fromJson} {
+		if loaded.Hash != c.Hash || loaded.Draft != c.Draft || string(loaded.Grammar) != string(c.Grammar) {
+			t.Fatalf("expected %#v, but got %#v", c, loaded)
+		}
+		v, err := loaded.Validator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Schema().JsonString() != string(c.Schema) {
+			t.Fatalf("expected schema %s, but got %s", c.Schema, v.Schema().JsonString())
+		}
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewSchemaCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Compile(cachedSchema); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, HashSchema(cachedSchema, Draft4)+".grammar")
+	if _, err := os.Stat(filename); err != nil {
+		t.Fatalf("expected a cached grammar: %v", err)
+	}
+	//a corrupt file is replaced
+	if err := ioutil.WriteFile(filename, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := cache.Compile(cachedSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &CompiledSchema{}
+	if err := c.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected the corrupt file to be replaced: %v", err)
+	}
+	if err := v.Validate([]byte(`{"a": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate([]byte(`{"a": 4}`)); err == nil {
+		t.Fatalf("expected a cached validator to reject a value above the maximum")
+	}
+	if _, err := cache.Compile([]byte(`{"enum": [1]}`)); err == nil {
+		t.Fatalf("expected an unsupported schema to fail")
+	}
+}
+
+//countingCache returns a cache that counts the schemas that it translates.
+func countingCache(t *testing.T, dir string) (*SchemaCache, *int) {
+	cache, err := NewSchemaCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translations := 0
+	cache.compile = func(schema []byte, opts ...Option) (*CompiledSchema, error) {
+		translations++
+		return CompileToCache(schema, opts...)
+	}
+	return cache, &translations
+}
+
+func TestSchemaCacheHit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := CompileToCache(cachedSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, c.Hash+".grammar"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, translations := countingCache(t, dir)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Compile(cachedSchema); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *translations != 0 {
+		t.Fatalf("expected the cached file to be loaded without translating, but got %d translations", *translations)
+	}
+}
+
+func TestSchemaCacheStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stale, err := CompileToCache([]byte(`{"type": "object"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := stale.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, HashSchema(cachedSchema, Draft4)+".grammar")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, translations := countingCache(t, dir)
+	if _, err := cache.Compile(cachedSchema); err != nil {
+		t.Fatal(err)
+	}
+	if *translations != 1 {
+		t.Fatalf("expected the stale file to be translated again, but got %d translations", *translations)
+	}
+	data, err = ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &CompiledSchema{}
+	if err := c.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if c.Stale(cachedSchema) {
+		t.Fatalf("expected the stale file to be replaced, but it has hash %s", c.Hash)
+	}
+}
@@ -0,0 +1,277 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+//Command jsonschema validates json files against a json schema.
+//
+//	jsonschema validate -s schema.json [-r dir]... [-o text|json|junit] [-draft 4] data/*.json
+//
+//References to other schema files are resolved relative to the schema and in the -r directories,
+//see jsonschema.InlineRefs.
+//Patterns in the file arguments are expanded, for shells that do not expand them.
+//
+//The exit code is 0 if every file is valid, 1 if any file is invalid or cannot be read,
+//2 for invalid arguments and 3 if the schema cannot be read or translated.
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"github.com/awalterschulze/jsonschema"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+	exitSchema  = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func usage(stderr io.Writer) {
+	fmt.Fprintf(stderr, "usage: jsonschema validate -s schema.json [flags] files...\n")
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "validate" {
+		usage(stderr)
+		return exitUsage
+	}
+	return validate(args[1:], stdout, stderr)
+}
+
+//dirs is a flag that can be repeated.
+type dirs []string
+
+func (this *dirs) String() string {
+	return strings.Join(*this, ",")
+}
+
+func (this *dirs) Set(dir string) error {
+	*this = append(*this, dir)
+	return nil
+}
+
+//result is the validation result of one file.
+type result struct {
+	File     string                `json:"file"`
+	Valid    bool                  `json:"valid"`
+	Failures []*jsonschema.Failure `json:"failures,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+func validate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("jsonschema validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	schemaFile := flags.String("s", "", "the json schema file")
+	var refDirs dirs
+	flags.Var(&refDirs, "r", "a directory where referenced schemas are looked up, can be repeated")
+	output := flags.String("o", "text", "the output format: text, json or junit")
+	draft := flags.Int("draft", int(jsonschema.Draft4), "the json schema draft that the schema is translated with")
+	flags.Usage = func() {
+		usage(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(*schemaFile) == 0 || flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	var write func(io.Writer, string, []*result) error
+	switch *output {
+	case "text":
+		write = writeText
+	case "json":
+		write = writeJson
+	case "junit":
+		write = writeJUnit
+	default:
+		fmt.Fprintf(stderr, "jsonschema: unknown output format %q\n", *output)
+		return exitUsage
+	}
+	files, err := expand(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %v\n", err)
+		return exitUsage
+	}
+
+	v, err := compile(*schemaFile, refDirs, jsonschema.Draft(*draft))
+	if err != nil {
+		if terr, ok := err.(*jsonschema.TranslateError); ok {
+			fmt.Fprintf(stderr, "%s#%s: unsupported keyword %s: %s\n", *schemaFile, terr.SchemaPointer, terr.Keyword, terr.Message)
+		} else {
+			fmt.Fprintf(stderr, "%s: %v\n", *schemaFile, err)
+		}
+		return exitSchema
+	}
+
+	code := exitOK
+	results := make([]*result, len(files))
+	for i, file := range files {
+		results[i] = validateFile(v, file)
+		if !results[i].Valid {
+			code = exitInvalid
+		}
+	}
+	if err := write(stdout, *schemaFile, results); err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %v\n", err)
+		return exitInvalid
+	}
+	return code
+}
+
+//expand replaces the patterns in the arguments with the files that they match.
+func expand(args []string) ([]string, error) {
+	files := []string{}
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func compile(filename string, refDirs []string, draft jsonschema.Draft) (*jsonschema.Validator, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err = jsonschema.InlineRefs(data, filename, refDirs...)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.Compile(data, jsonschema.WithDraft(draft))
+}
+
+func validateFile(v *jsonschema.Validator, file string) *result {
+	r := &result{File: file}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	err = v.Validate(data)
+	if err == nil {
+		r.Valid = true
+		return r
+	}
+	if verr, ok := err.(*jsonschema.ValidationError); ok {
+		r.Failures = verr.Failures
+	} else {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+func writeText(w io.Writer, schema string, results []*result) error {
+	for _, r := range results {
+		var err error
+		switch {
+		case r.Valid:
+			_, err = fmt.Fprintf(w, "%s: ok\n", r.File)
+		case len(r.Error) > 0:
+			_, err = fmt.Fprintf(w, "%s: error: %s\n", r.File, r.Error)
+		default:
+			_, err = fmt.Fprintf(w, "%s: invalid\n", r.File)
+			for _, f := range r.Failures {
+				if err == nil {
+					_, err = fmt.Fprintf(w, "\t%s\n", f)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJson(w io.Writer, schema string, results []*result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(results)
+}
+
+type junitSuite struct {
+	XMLName  xml.Name     `xml:"testsuite"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Cases    []*junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, schema string, results []*result) error {
+	suite := &junitSuite{Name: schema, Tests: len(results)}
+	for _, r := range results {
+		c := &junitCase{Name: r.File, ClassName: schema}
+		if len(r.Error) > 0 {
+			suite.Errors++
+			c.Error = &junitMessage{Message: r.Error}
+		} else if !r.Valid {
+			suite.Failures++
+			lines := make([]string, len(r.Failures))
+			for i, f := range r.Failures {
+				lines[i] = f.String()
+			}
+			c.Failure = &junitMessage{
+				Message: "does not match the schema",
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,114 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "jsonschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var testFiles = map[string]string{
+	"schema.json":      `{"properties": {"a": {"$ref": "defs/small.json"}}}`,
+	"defs/small.json":  `{"type": "integer", "maximum": 3}`,
+	"data/valid.json":  `{"a": 1}`,
+	"data/wrong.json":  `{"a": 4}`,
+	"data/broken.json": `{"a": `,
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeFiles(t, testFiles)
+	defer os.RemoveAll(dir)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"validate", "-s", filepath.Join(dir, "schema.json"), filepath.Join(dir, "data", "*.json")}
+	if code := run(args, stdout, stderr); code != exitInvalid {
+		t.Fatalf("expected exit code %d, but got %d: %s", exitInvalid, code, stderr)
+	}
+	out := stdout.String()
+	for _, want := range []string{"broken.json: error:", "valid.json: ok", "wrong.json: invalid", "/a: "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestValidateOk(t *testing.T) {
+	dir := writeFiles(t, testFiles)
+	defer os.RemoveAll(dir)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"validate", "-s", filepath.Join(dir, "schema.json"), "-o", "json", filepath.Join(dir, "data", "valid.json")}
+	if code := run(args, stdout, stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, but got %d: %s", exitOK, code, stderr)
+	}
+	results := []*result{}
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Valid {
+		t.Fatalf("unexpected results %s", stdout)
+	}
+}
+
+func TestValidateJUnit(t *testing.T) {
+	dir := writeFiles(t, testFiles)
+	defer os.RemoveAll(dir)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"validate", "-s", filepath.Join(dir, "schema.json"), "-o", "junit", filepath.Join(dir, "data", "*.json")}
+	if code := run(args, stdout, stderr); code != exitInvalid {
+		t.Fatalf("expected exit code %d, but got %d: %s", exitInvalid, code, stderr)
+	}
+	suite := &junitSuite{}
+	if err := xml.Unmarshal(stdout.Bytes(), suite); err != nil {
+		t.Fatal(err)
+	}
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Errors != 1 {
+		t.Fatalf("unexpected test suite %s", stdout)
+	}
+}
+
+func TestValidateUsage(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run([]string{"validate", "data.json"}, stdout, stderr); code != exitUsage {
+		t.Fatalf("expected exit code %d without a schema, but got %d", exitUsage, code)
+	}
+	if code := run([]string{"check"}, stdout, stderr); code != exitUsage {
+		t.Fatalf("expected exit code %d for an unknown command, but got %d", exitUsage, code)
+	}
+	if code := run([]string{"validate", "-s", "missing.json", "data.json"}, stdout, stderr); code != exitSchema {
+		t.Fatalf("expected exit code %d for a missing schema, but got %d", exitSchema, code)
+	}
+}
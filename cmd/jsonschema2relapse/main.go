@@ -0,0 +1,115 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+//Command jsonschema2relapse translates a json schema into a relapse grammar.
+//
+//	jsonschema2relapse [-draft 4] [-format relapse|json] [schema.json]
+//
+//The schema is read from the file or, if no file or "-" is given, from stdin.
+//The grammar is written to stdout as formatted relapse source or as the json encoded relapse ast.
+//
+//The exit code is 0 on success, 1 if the schema cannot be read or parsed or the output cannot be written,
+//2 for invalid arguments and 3 if the schema uses a feature that cannot be translated.
+//Untranslatable features are reported with the json pointer to their keyword.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/awalterschulze/jsonschema"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitUnsupported = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("jsonschema2relapse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	draft := flags.Int("draft", int(jsonschema.Draft4), "the json schema draft that the schema is translated with")
+	format := flags.String("format", "relapse", "the output format: relapse or json")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: jsonschema2relapse [flags] [schema.json]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *format != "relapse" && *format != "json" {
+		fmt.Fprintf(stderr, "jsonschema2relapse: unknown format %q\n", *format)
+		return exitUsage
+	}
+	if !jsonschema.Draft(*draft).Supported() {
+		fmt.Fprintf(stderr, "jsonschema2relapse: unknown draft %d\n", *draft)
+		return exitUsage
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	filename := "-"
+	if flags.NArg() == 1 {
+		filename = flags.Arg(0)
+	}
+
+	var data []byte
+	var err error
+	if filename == "-" {
+		filename = "<stdin>"
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonschema2relapse: %v\n", err)
+		return exitFailure
+	}
+	schema, err := jsonschema.ParseSchema(data)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", filename, err)
+		return exitFailure
+	}
+	g, err := jsonschema.Draft(*draft).Translate(schema)
+	if err != nil {
+		if terr, ok := err.(*jsonschema.TranslateError); ok {
+			fmt.Fprintf(stderr, "%s#%s: unsupported keyword %s: %s\n", filename, terr.SchemaPointer, terr.Keyword, terr.Message)
+		} else {
+			fmt.Fprintf(stderr, "%s: %v\n", filename, err)
+		}
+		return exitUnsupported
+	}
+
+	if *format == "json" {
+		out, err := json.MarshalIndent(g, "", "\t")
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonschema2relapse: %v\n", err)
+			return exitFailure
+		}
+		fmt.Fprintf(stdout, "%s\n", out)
+		return exitOK
+	}
+	fmt.Fprint(stdout, jsonschema.FormatRelapse(g.String()))
+	return exitOK
+}
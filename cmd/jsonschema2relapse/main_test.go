@@ -0,0 +1,71 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	stdin := strings.NewReader(`{"properties": {"a": {"type": "string"}}}`)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run(nil, stdin, stdout, stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, but got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "\n\ta:") {
+		t.Fatalf("expected formatted relapse, but got %s", stdout)
+	}
+}
+
+func TestRunJson(t *testing.T) {
+	stdin := strings.NewReader(`{"type": "string"}`)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run([]string{"-format", "json", "-"}, stdin, stdout, stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, but got %d: %s", exitOK, code, stderr)
+	}
+	if !json.Valid(stdout.Bytes()) {
+		t.Fatalf("expected json, but got %s", stdout)
+	}
+}
+
+func TestRunUnsupported(t *testing.T) {
+	stdin := strings.NewReader(`{"properties": {"a": {"uniqueItems": true}}}`)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run(nil, stdin, stdout, stderr); code != exitUnsupported {
+		t.Fatalf("expected exit code %d, but got %d", exitUnsupported, code)
+	}
+	if !strings.Contains(stderr.String(), "<stdin>#/properties/a/uniqueItems") {
+		t.Fatalf("expected the keyword location, but got %s", stderr)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run(nil, strings.NewReader(`{"type": `), stdout, stderr); code != exitFailure {
+		t.Fatalf("expected exit code %d for a parse error, but got %d", exitFailure, code)
+	}
+	if code := run([]string{"-format", "xml"}, strings.NewReader(`{}`), stdout, stderr); code != exitUsage {
+		t.Fatalf("expected exit code %d for an unknown format, but got %d", exitUsage, code)
+	}
+	if code := run([]string{"-draft", "3"}, strings.NewReader(`{}`), stdout, stderr); code != exitUsage {
+		t.Fatalf("expected exit code %d for an unknown draft, but got %d", exitUsage, code)
+	}
+	if code := run([]string{"does-not-exist.json"}, nil, stdout, stderr); code != exitFailure {
+		t.Fatalf("expected exit code %d for a missing file, but got %d", exitFailure, code)
+	}
+}
@@ -0,0 +1,328 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//go test -run TestCompliance -compliance
+var updateCompliance = flag.Bool("compliance", false, "rewrite compliance.json and the compliance section of Readme.md")
+
+const (
+	suitePath         = "./JSON-Schema-Test-Suite/tests/"
+	compliancePath    = "./compliance.json"
+	readmePath        = "./Readme.md"
+	complianceBegin   = "<!-- compliance -->"
+	complianceEnd     = "<!-- /compliance -->"
+	resultPass        = "pass"
+	resultFail        = "fail"
+	resultUnsupported = "unsupported"
+)
+
+type complianceReport struct {
+	Drafts []*complianceDraft `json:"drafts"`
+}
+
+type complianceDraft struct {
+	Draft string            `json:"draft"`
+	Files []*complianceFile `json:"files"`
+}
+
+type complianceFile struct {
+	File  string            `json:"file"`
+	Cases []*complianceCase `json:"cases"`
+}
+
+type complianceCase struct {
+	Schema string `json:"schema"`
+	Test   string `json:"test"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+	//Keyword is the unsupported keyword, if it is known.
+	Keyword string `json:"keyword,omitempty"`
+}
+
+func (this *complianceFile) count(result string) int {
+	n := 0
+	for _, c := range this.Cases {
+		if c.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+func (this *complianceDraft) count(result string) int {
+	n := 0
+	for _, f := range this.Files {
+		n += f.count(result)
+	}
+	return n
+}
+
+//runCase classifies a single test of the suite.
+//A schema that cannot be parsed or translated is unsupported.
+func runCase(draft Draft, schema []byte, data []byte, valid bool) *complianceCase {
+	c := &complianceCase{}
+	v, err := Compile(schema, WithDraft(draft))
+	if err != nil {
+		c.Result = resultUnsupported
+		c.Error = err.Error()
+		if terr, ok := err.(*TranslateError); ok {
+			c.Keyword = terr.Keyword
+		}
+		return c
+	}
+	err = v.Validate(data)
+	if err != nil && !errors.Is(err, ErrInvalid) {
+		c.Result = resultFail
+		c.Error = err.Error()
+		return c
+	}
+	if (err == nil) != valid {
+		c.Result = resultFail
+		c.Error = fmt.Sprintf("expected valid %v", valid)
+		return c
+	}
+	c.Result = resultPass
+	return c
+}
+
+func buildComplianceReport(t *testing.T) *complianceReport {
+	dirs, err := ioutil.ReadDir(suitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &complianceReport{}
+	for _, dir := range dirs {
+		if !dir.IsDir() || !strings.HasPrefix(dir.Name(), "draft") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(dir.Name(), "draft"))
+		if err != nil {
+			continue
+		}
+		draft := &complianceDraft{Draft: dir.Name()}
+		root := filepath.Join(suitePath, dir.Name())
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			file := &complianceFile{File: filepath.ToSlash(rel)}
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var schemaTests []*SchemaTest
+			if err := json.Unmarshal(content, &schemaTests); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			for _, schemaTest := range schemaTests {
+				schema, err := json.Marshal(schemaTest.Schema)
+				if err != nil {
+					return err
+				}
+				for _, test := range schemaTest.Tests {
+					data, err := json.Marshal(test.Data)
+					if err != nil {
+						return err
+					}
+					c := runCase(Draft(n), schema, data, test.Valid)
+					c.Schema = schemaTest.Description
+					c.Test = test.Description
+					file.Cases = append(file.Cases, c)
+				}
+			}
+			draft.Files = append(draft.Files, file)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		report.Drafts = append(report.Drafts, draft)
+	}
+	return report
+}
+
+//markdown summarizes the report per draft and per file.
+func (this *complianceReport) markdown() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "This summary is generated from the [JSON-Schema-Test-Suite](https://github.com/json-schema/JSON-Schema-Test-Suite) by `go test -run TestCompliance -compliance`, which also writes the result of every test to [compliance.json](compliance.json).\n")
+	fmt.Fprintf(buf, "A test is unsupported if its schema cannot be parsed or translated.\n\n")
+	fmt.Fprintf(buf, "| Draft | Pass | Fail | Unsupported |\n|---|---|---|---|\n")
+	for _, d := range this.Drafts {
+		fmt.Fprintf(buf, "| %s | %d | %d | %d |\n", d.Draft, d.count(resultPass), d.count(resultFail), d.count(resultUnsupported))
+	}
+	for _, d := range this.Drafts {
+		fmt.Fprintf(buf, "\n### %s\n\n", d.Draft)
+		if d.count(resultPass) == 0 && d.count(resultFail) == 0 {
+			fmt.Fprintf(buf, "Not supported.\n")
+			continue
+		}
+		fmt.Fprintf(buf, "| File | Pass | Fail | Unsupported |\n|---|---|---|---|\n")
+		keywords := make(map[string]int)
+		for _, f := range d.Files {
+			fmt.Fprintf(buf, "| %s | %d | %d | %d |\n", f.File, f.count(resultPass), f.count(resultFail), f.count(resultUnsupported))
+			for _, c := range f.Cases {
+				if len(c.Keyword) > 0 {
+					keywords[c.Keyword]++
+				}
+			}
+		}
+		if len(keywords) > 0 {
+			names := make([]string, 0, len(keywords))
+			for name := range keywords {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Fprintf(buf, "\nUnsupported keywords:\n")
+			for _, name := range names {
+				fmt.Fprintf(buf, "  - %s (%d tests)\n", name, keywords[name])
+			}
+		}
+	}
+	fmt.Fprintf(buf, "\nAlso note that relapse cannot distinguish between `\"type\":\"object\"` and `\"type\":\"array\"`.\n")
+	return buf.String()
+}
+
+func section(doc, begin, end string) (string, error) {
+	i := strings.Index(doc, begin)
+	j := strings.Index(doc, end)
+	if i < 0 || j < i {
+		return "", fmt.Errorf("%s ... %s not found", begin, end)
+	}
+	return strings.TrimPrefix(doc[i+len(begin):j], "\n"), nil
+}
+
+func replaceSection(doc, begin, end, section string) (string, error) {
+	i := strings.Index(doc, begin)
+	j := strings.Index(doc, end)
+	if i < 0 || j < i {
+		return "", fmt.Errorf("%s ... %s not found", begin, end)
+	}
+	return doc[:i+len(begin)] + "\n" + section + doc[j:], nil
+}
+
+func (this *complianceReport) results() map[string]*complianceCase {
+	results := make(map[string]*complianceCase)
+	for _, d := range this.Drafts {
+		for _, f := range d.Files {
+			for _, c := range f.Cases {
+				results[d.Draft+"/"+f.File+":"+c.Schema+":"+c.Test] = c
+			}
+		}
+	}
+	return results
+}
+
+//TestCompliance runs the whole test suite, including the optional tests and other drafts.
+//It is skipped until compliance.json is generated and then fails if compliance.json or
+//the compliance section of Readme.md is outdated, which is fixed by running go test -run TestCompliance -compliance.
+//A test that passes according to compliance.json and no longer passes is reported as a regression.
+func TestCompliance(t *testing.T) {
+	report := buildComplianceReport(t)
+	for _, d := range report.Drafts {
+		t.Logf("%s: pass %d, fail %d, unsupported %d", d.Draft, d.count(resultPass), d.count(resultFail), d.count(resultUnsupported))
+	}
+	if *updateCompliance {
+		data, err := json.MarshalIndent(report, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(compliancePath, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		readme, err := ioutil.ReadFile(readmePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated, err := replaceSection(string(readme), complianceBegin, complianceEnd, report.markdown())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(readmePath, []byte(updated), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := ioutil.ReadFile(compliancePath)
+	if os.IsNotExist(err) {
+		t.Skipf("%s has not been generated, run go test -run TestCompliance -compliance", compliancePath)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := &complianceReport{}
+	if err := json.Unmarshal(data, previous); err != nil {
+		t.Fatal(err)
+	}
+	results := report.results()
+	previousResults := previous.results()
+	outdated := []string{}
+	for name, c := range previousResults {
+		now, ok := results[name]
+		if !ok {
+			outdated = append(outdated, name+": removed")
+			continue
+		}
+		if c.Result == now.Result {
+			continue
+		}
+		if c.Result == resultPass {
+			t.Errorf("--- REGRESSION: %s: %s %s", name, now.Result, now.Error)
+		} else {
+			outdated = append(outdated, name+": "+c.Result+" is now "+now.Result)
+		}
+	}
+	for name := range results {
+		if _, ok := previousResults[name]; !ok {
+			outdated = append(outdated, name+": added")
+		}
+	}
+	if len(outdated) > 0 {
+		sort.Strings(outdated)
+		if len(outdated) > 10 {
+			outdated = append(outdated[:10], "...")
+		}
+		t.Errorf("%s is outdated, run go test -run TestCompliance -compliance:\n%s", compliancePath, strings.Join(outdated, "\n"))
+	}
+	readme, err := ioutil.ReadFile(readmePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := section(string(readme), complianceBegin, complianceEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != report.markdown() {
+		t.Errorf("the compliance section of %s is outdated, run go test -run TestCompliance -compliance", readmePath)
+	}
+}
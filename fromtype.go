@@ -0,0 +1,453 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//FromType returns a draft-04 schema for the json that encoding/json produces for values of the Go type.
+//
+//Struct fields are named by their json tags and fields with the omitempty option are not required.
+//Pointers are nullable, but nil slices and maps, which are encoded as null, are not.
+//Maps are objects with additionalProperties and slices and arrays are arrays with items.
+//Named struct types that are used more than once, or recursively, are placed in definitions and referenced with $ref.
+//
+//Constraints are added with the jsonschema struct tag, for example:
+//  Age  int    `json:"age" jsonschema:"minimum=0,maximum=150"`
+//  Code string `json:"code" jsonschema:"pattern=^[A-Z]{2,3}$"`
+//The supported constraints are title, description, format, minimum, maximum, exclusiveMinimum,
+//exclusiveMaximum, multipleOf, minLength, maxLength, pattern, minItems, maxItems, uniqueItems and
+//enum, with values separated by |.
+func FromType(t reflect.Type) (*Schema, error) {
+	pointers := 0
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		pointers++
+	}
+	f := &fromType{
+		root:  t,
+		uses:  make(map[reflect.Type]int),
+		names: make(map[reflect.Type]string),
+		taken: make(map[string]bool),
+	}
+	f.count(t, make(map[reflect.Type]bool))
+	var schema *Schema
+	var err error
+	if isNamedStruct(t) {
+		//the root is not placed in definitions, since it can be referenced with #.
+		schema, err = f.structSchema(t)
+	} else {
+		schema, err = f.schema(t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pointers > 0 {
+		schema = nullable(schema)
+	}
+	if len(f.definitions) > 0 {
+		schema.Definitions = f.definitions
+	}
+	schema.Schema = draft4URI
+	return schema, nil
+}
+
+type fromType struct {
+	root reflect.Type
+	//uses counts the places where each named struct type is used.
+	uses map[reflect.Type]int
+	//names are the definition names of the named struct types that are referenced.
+	names       map[reflect.Type]string
+	taken       map[string]bool
+	definitions map[string]*Schema
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func isNamedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && len(t.Name()) > 0 && t != timeType
+}
+
+//count counts the uses of named struct types, without looking inside a type twice.
+func (this *fromType) count(t reflect.Type, seen map[reflect.Type]bool) {
+	if isNamedStruct(t) {
+		this.uses[t]++
+	}
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		this.count(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range structFields(t) {
+			this.count(f.typ, seen)
+		}
+	}
+}
+
+//ref returns a reference to the named struct type, if it is used more than once.
+func (this *fromType) ref(t reflect.Type) (*Schema, bool, error) {
+	if !isNamedStruct(t) || this.uses[t] < 2 {
+		return nil, false, nil
+	}
+	if t == this.root {
+		return &Schema{Ref: "#"}, true, nil
+	}
+	if name, ok := this.names[t]; ok {
+		return &Schema{Ref: "#/definitions/" + escapePointer(name)}, true, nil
+	}
+	name := t.Name()
+	if this.taken[name] {
+		name = strings.Replace(t.PkgPath(), "/", ".", -1) + "." + t.Name()
+	}
+	this.names[t] = name
+	this.taken[name] = true
+	if this.definitions == nil {
+		this.definitions = make(map[string]*Schema)
+	}
+	s, err := this.structSchema(t)
+	if err != nil {
+		return nil, false, err
+	}
+	this.definitions[name] = s
+	return &Schema{Ref: "#/definitions/" + escapePointer(name)}, true, nil
+}
+
+func (this *fromType) schema(t reflect.Type) (*Schema, error) {
+	if t == timeType {
+		return &Schema{Type: &Type{TypeString}, Format: "date-time"}, nil
+	}
+	if t.Kind() != reflect.Ptr && (t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType)) {
+		//the json is only known to the MarshalJSON method.
+		return &Schema{}, nil
+	}
+	if t.Kind() != reflect.Ptr && (t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) {
+		return &Schema{Type: &Type{TypeString}}, nil
+	}
+	if s, ok, err := this.ref(t); ok || err != nil {
+		return s, err
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: &Type{TypeBoolean}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: &Type{TypeInteger}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &Schema{Type: &Type{TypeInteger}, Numeric: Numeric{Minimum: &zero}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: &Type{TypeNumber}}, nil
+	case reflect.String:
+		return &Schema{Type: &Type{TypeString}}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Ptr:
+		elem, err := this.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(elem), nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			//[]byte is encoded as a base64 string.
+			return &Schema{Type: &Type{TypeString}}, nil
+		}
+		items, err := this.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: &Type{TypeArray}, Array: Array{Items: &Items{Object: items}}}
+		if t.Kind() == reflect.Array {
+			n := uint64(t.Len())
+			s.MinItems = n
+			s.MaxItems = &n
+		}
+		return s, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return nil, fmt.Errorf("map key type %v is not supported", t.Key())
+			}
+		}
+		elem, err := this.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: &Type{TypeObject}}
+		//additionalProperties only supports a single type.
+		if elem.Type != nil && len(*elem.Type) == 1 {
+			s.AdditionalProperties = &Additional{Type: (*elem.Type)[0]}
+		}
+		return s, nil
+	case reflect.Struct:
+		return this.structSchema(t)
+	}
+	return nil, fmt.Errorf("type %v of kind %v is not supported", t, t.Kind())
+}
+
+//nullable returns a schema that also allows null.
+func nullable(s *Schema) *Schema {
+	if s.Type != nil {
+		types := append(Type{}, *s.Type...)
+		if !types.has(TypeNull) {
+			types = append(types, TypeNull)
+		}
+		s.Type = &types
+		return s
+	}
+	if len(s.Ref) == 0 {
+		//a schema without a type already allows null.
+		return s
+	}
+	return &Schema{Instance: Instance{AnyOf: []*Schema{s, {Type: &Type{TypeNull}}}}}
+}
+
+//goField is a struct field as it is encoded by encoding/json.
+type goField struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool
+	asString  bool
+	tag       string
+}
+
+//structFields returns the encoded fields of the struct, including the promoted fields of embedded structs.
+//The fields of the struct itself take precedence over promoted fields with the same name.
+func structFields(t reflect.Type) []*goField {
+	var fields []*goField
+	names := make(map[string]bool)
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		typ := f.Type
+		if f.Anonymous && len(name) == 0 {
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			if typ.Kind() == reflect.Struct {
+				embedded = append(embedded, typ)
+				continue
+			}
+		}
+		if len(f.PkgPath) > 0 {
+			//unexported
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		ff := &goField{name: name, typ: f.Type, tag: f.Tag.Get("jsonschema")}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				ff.omitempty = true
+			case "string":
+				ff.asString = true
+			}
+		}
+		names[name] = true
+		fields = append(fields, ff)
+	}
+	for _, e := range embedded {
+		for _, f := range structFields(e) {
+			if names[f.name] {
+				continue
+			}
+			names[f.name] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func (this *fromType) structSchema(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: &Type{TypeObject}, Object: Object{Properties: make(map[string]*Schema)}}
+	for _, f := range structFields(t) {
+		p, err := this.schema(f.typ)
+		if err != nil {
+			return nil, fmt.Errorf("%v.%s: %v", t, f.name, err)
+		}
+		if f.asString {
+			switch f.typ.Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+				reflect.Float32, reflect.Float64, reflect.String:
+				p = &Schema{Type: &Type{TypeString}}
+			}
+		}
+		if len(f.tag) > 0 {
+			if len(p.Ref) > 0 {
+				p = &Schema{Instance: Instance{AllOf: []*Schema{p}}}
+			}
+			if err := constrain(p, f.tag); err != nil {
+				return nil, fmt.Errorf("%v.%s: %v", t, f.name, err)
+			}
+		}
+		s.Properties[f.name] = p
+		if !f.omitempty {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+	return s, nil
+}
+
+var constraintFlags = map[string]bool{
+	"exclusiveMinimum": true,
+	"exclusiveMaximum": true,
+	"uniqueItems":      true,
+}
+
+var constraintKeys = map[string]bool{
+	"title":       true,
+	"description": true,
+	"format":      true,
+	"minimum":     true,
+	"maximum":     true,
+	"multipleOf":  true,
+	"minLength":   true,
+	"maxLength":   true,
+	"pattern":     true,
+	"minItems":    true,
+	"maxItems":    true,
+	"enum":        true,
+}
+
+//splitConstraints splits the jsonschema tag on the commas that are followed by a constraint,
+//so that values, like patterns, can contain commas.
+func splitConstraints(tag string) []string {
+	var parts []string
+	for _, part := range strings.Split(tag, ",") {
+		key := part
+		if i := strings.Index(part, "="); i >= 0 {
+			key = part[:i]
+		}
+		if len(parts) > 0 && !constraintFlags[key] && !(constraintKeys[key] && key != part) {
+			parts[len(parts)-1] += "," + part
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+//constrain adds the constraints of a jsonschema struct tag to the schema.
+func constrain(s *Schema, tag string) error {
+	for _, part := range splitConstraints(tag) {
+		if constraintFlags[part] {
+			switch part {
+			case "exclusiveMinimum":
+				s.ExclusiveMinimum = true
+			case "exclusiveMaximum":
+				s.ExclusiveMaximum = true
+			case "uniqueItems":
+				s.UniqueItems = true
+			}
+			continue
+		}
+		i := strings.Index(part, "=")
+		if i < 0 || !constraintKeys[part[:i]] {
+			return fmt.Errorf("unknown constraint %q", part)
+		}
+		key, value := part[:i], part[i+1:]
+		var err error
+		switch key {
+		case "title":
+			s.Title = value
+		case "description":
+			s.Description = value
+		case "format":
+			s.Format = value
+		case "pattern":
+			s.Pattern = &value
+		case "minimum":
+			s.Minimum, err = parseConstraintFloat(value)
+		case "maximum":
+			s.Maximum, err = parseConstraintFloat(value)
+		case "multipleOf":
+			s.MultipleOf, err = parseConstraintFloat(value)
+		case "minLength":
+			err = parseConstraintUint(value, &s.MinLength)
+		case "maxLength":
+			s.MaxLength = new(uint64)
+			err = parseConstraintUint(value, s.MaxLength)
+		case "minItems":
+			err = parseConstraintUint(value, &s.MinItems)
+		case "maxItems":
+			s.MaxItems = new(uint64)
+			err = parseConstraintUint(value, s.MaxItems)
+		case "enum":
+			s.Enum, err = parseConstraintEnum(s, value)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func parseConstraintFloat(value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func parseConstraintUint(value string, u *uint64) error {
+	var err error
+	*u, err = strconv.ParseUint(value, 10, 64)
+	return err
+}
+
+//parseConstraintEnum parses the values of a string schema as strings and other values as json.
+func parseConstraintEnum(s *Schema, value string) ([]interface{}, error) {
+	var enum []interface{}
+	for _, v := range strings.Split(value, "|") {
+		if s.Type != nil && s.Type.has(TypeString) {
+			enum = append(enum, v)
+			continue
+		}
+		instance, err := decodeInstance([]byte(v))
+		if err != nil {
+			return nil, err
+		}
+		enum = append(enum, instance)
+	}
+	return enum, nil
+}
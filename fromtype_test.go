@@ -0,0 +1,126 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fromTypeAddress struct {
+	Street string `json:"street"`
+	Code   string `json:"code,omitempty" jsonschema:"pattern=^[0-9]{4,5}$,maxLength=5"`
+}
+
+type fromTypeBase struct {
+	Id      uint64    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type fromTypePerson struct {
+	fromTypeBase
+	Name     string                 `json:"name" jsonschema:"minLength=1,title=Full name"`
+	Age      *int                   `json:"age" jsonschema:"minimum=0,maximum=150"`
+	Kind     string                 `json:"kind" jsonschema:"enum=a|b"`
+	Home     fromTypeAddress        `json:"home"`
+	Work     *fromTypeAddress       `json:"work,omitempty"`
+	Tags     []string               `json:"tags,omitempty" jsonschema:"uniqueItems"`
+	Scores   map[string]float64     `json:"scores,omitempty"`
+	Extra    map[string]interface{} `json:"-"`
+	Parent   *fromTypePerson        `json:"parent,omitempty"`
+	Count    int64                  `json:"count,string"`
+	internal int
+}
+
+func TestFromType(t *testing.T) {
+	schema, err := FromType(reflect.TypeOf(fromTypePerson{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseSchema([]byte(`{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "minimum": 0},
+			"created": {"type": "string", "format": "date-time"},
+			"name": {"type": "string", "minLength": 1, "title": "Full name"},
+			"age": {"type": ["integer", "null"], "minimum": 0, "maximum": 150},
+			"kind": {"type": "string", "enum": ["a", "b"]},
+			"home": {"$ref": "#/definitions/fromTypeAddress"},
+			"work": {"anyOf": [{"$ref": "#/definitions/fromTypeAddress"}, {"type": "null"}]},
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+			"scores": {"type": "object", "additionalProperties": {"type": "number"}},
+			"parent": {"anyOf": [{"$ref": "#"}, {"type": "null"}]},
+			"count": {"type": "string"}
+		},
+		"required": ["name", "age", "kind", "home", "count", "id", "created"],
+		"definitions": {
+			"fromTypeAddress": {
+				"type": "object",
+				"properties": {
+					"street": {"type": "string"},
+					"code": {"type": "string", "pattern": "^[0-9]{4,5}$", "maxLength": 5}
+				},
+				"required": ["street"]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.JsonString() != want.JsonString() {
+		t.Fatalf("expected %s, but got %s", want.JsonString(), schema.JsonString())
+	}
+}
+
+func TestFromTypeRoundTrip(t *testing.T) {
+	schema, err := FromType(reflect.TypeOf(&fromTypePerson{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.JsonString() != schema.JsonString() {
+		t.Fatalf("expected %s, but got %s", schema.JsonString(), parsed.JsonString())
+	}
+	if !parsed.Type.has(TypeNull) {
+		t.Fatalf("expected a pointer to be nullable")
+	}
+}
+
+func TestFromTypeErrors(t *testing.T) {
+	type badTag struct {
+		A int `jsonschema:"minimum=one"`
+	}
+	type badKind struct {
+		C chan int
+	}
+	type badConstraint struct {
+		A int `jsonschema:"maximal=1"`
+	}
+	for _, typ := range []reflect.Type{reflect.TypeOf(badTag{}), reflect.TypeOf(badKind{}), reflect.TypeOf(badConstraint{})} {
+		if _, err := FromType(typ); err == nil {
+			t.Errorf("expected an error for %v", typ)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+)
+
+//Generator generates random json instances that are valid against a schema, for property based testing.
+//Candidates are built from the type, numeric, string, object, array and enum keywords,
+//by picking one of the subschemas of anyOf and oneOf and by merging the subschemas of allOf.
+//Candidates that are not valid against the whole schema, for example because of not, are discarded.
+//Candidates are checked with a Validator compiled from the schema or,
+//if the schema cannot be translated, with the diagnostic evaluation of the schema.
+//A Generator is not safe for concurrent use.
+type Generator struct {
+	rand *rand.Rand
+	//MaxDepth limits the nesting of objects and arrays, beyond what the schema requires.
+	MaxDepth int
+	//MaxAttempts is the number of candidates that are generated before giving up.
+	MaxAttempts int
+}
+
+//NewGenerator returns a Generator that always generates the same instances for the same seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		rand:        rand.New(rand.NewSource(seed)),
+		MaxDepth:    4,
+		MaxAttempts: 100,
+	}
+}
+
+//Generate returns a decoded json value that is valid against the schema.
+func (this *Generator) Generate(schema *Schema) (interface{}, error) {
+	valid := func(instance interface{}) (bool, error) {
+		return evalSchema(schema, instance, "", "").valid, nil
+	}
+	if v, err := CompileSchema(schema); err == nil {
+		valid = func(instance interface{}) (bool, error) {
+			data, err := json.Marshal(instance)
+			if err != nil {
+				return false, err
+			}
+			return v.interpret(data)
+		}
+	}
+	for i := 0; i < this.MaxAttempts; i++ {
+		instance, ok := this.generate(schema, 0)
+		if !ok {
+			continue
+		}
+		ok, err := valid(instance)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return instance, nil
+		}
+	}
+	return nil, fmt.Errorf("no valid instance found in %d attempts", this.MaxAttempts)
+}
+
+//GenerateJson returns json data that is valid against the schema.
+func (this *Generator) GenerateJson(schema *Schema) ([]byte, error) {
+	instance, err := this.Generate(schema)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(instance)
+}
+
+var allTypes = []SimpleType{TypeArray, TypeBoolean, TypeInteger, TypeNull, TypeNumber, TypeObject, TypeString}
+
+func (this *Generator) generate(schema *Schema, depth int) (interface{}, bool) {
+	if len(schema.Enum) > 0 {
+		return schema.Enum[this.rand.Intn(len(schema.Enum))], true
+	}
+	if len(schema.AllOf) > 0 {
+		merged := *schema
+		merged.AllOf = nil
+		for _, s := range schema.AllOf {
+			if m, ok := mergeSchemas(&merged, s); ok {
+				merged = *m
+			}
+		}
+		return this.generate(&merged, depth)
+	}
+	if len(schema.AnyOf) > 0 {
+		rest := *schema
+		rest.AnyOf = nil
+		return this.generate(this.choose(&rest, schema.AnyOf), depth)
+	}
+	if len(schema.OneOf) > 0 {
+		rest := *schema
+		rest.OneOf = nil
+		return this.generate(this.choose(&rest, schema.OneOf), depth)
+	}
+	typ := this.chooseType(schema, depth)
+	switch typ {
+	case TypeNull:
+		return nil, true
+	case TypeBoolean:
+		return this.rand.Intn(2) == 0, true
+	case TypeInteger, TypeNumber:
+		return this.number(schema.Numeric, typ == TypeInteger)
+	case TypeString:
+		return this.string(schema.String)
+	case TypeObject:
+		return this.object(schema.Object, depth)
+	case TypeArray:
+		return this.array(schema.Array, depth)
+	}
+	return nil, false
+}
+
+//choose picks one of the subschemas and merges it with the rest of the schema, if they do not have keywords in common.
+func (this *Generator) choose(rest *Schema, schemas []*Schema) *Schema {
+	s := schemas[this.rand.Intn(len(schemas))]
+	if m, ok := mergeSchemas(rest, s); ok {
+		return m
+	}
+	return s
+}
+
+//chooseType picks one of the types that the schema allows.
+//Without a type keyword the types are guessed from the other keywords.
+func (this *Generator) chooseType(schema *Schema, depth int) SimpleType {
+	if schema.Type != nil && len(*schema.Type) > 0 {
+		types := *schema.Type
+		return types[this.rand.Intn(len(types))]
+	}
+	types := []SimpleType{}
+	if schema.HasNumericConstraints() {
+		types = append(types, TypeNumber, TypeInteger)
+	}
+	if schema.HasStringConstraints() {
+		types = append(types, TypeString)
+	}
+	if schema.HasObjectConstraints() {
+		types = append(types, TypeObject)
+	}
+	if schema.HasArrayConstraints() {
+		types = append(types, TypeArray)
+	}
+	if len(types) == 0 {
+		for _, t := range allTypes {
+			if depth < this.MaxDepth || (t != TypeObject && t != TypeArray) {
+				types = append(types, t)
+			}
+		}
+	}
+	return types[this.rand.Intn(len(types))]
+}
+
+func (this *Generator) number(schema Numeric, integer bool) (interface{}, bool) {
+	const span = 100
+	lo, hi := -span/2.0, span/2.0
+	if schema.Minimum != nil && schema.Maximum != nil {
+		lo, hi = *schema.Minimum, *schema.Maximum
+	} else if schema.Minimum != nil {
+		lo, hi = *schema.Minimum, *schema.Minimum+span
+	} else if schema.Maximum != nil {
+		lo, hi = *schema.Maximum-span, *schema.Maximum
+	}
+	step := 0.0
+	if integer {
+		step = 1
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		step = *schema.MultipleOf
+		if integer {
+			//the smallest multiple of step that is also an integer, if step is a fraction like 0.5
+			m := step
+			for i := 2; m != math.Trunc(m) && i <= 100; i++ {
+				m = step * float64(i)
+			}
+			if m != math.Trunc(m) {
+				return nil, false
+			}
+			step = m
+		}
+	}
+	if hi-lo > 1<<40 {
+		hi = lo + 1<<40
+	}
+	if step == 0 {
+		v := lo + this.rand.Float64()*(hi-lo)
+		if (schema.ExclusiveMinimum && v == lo) || (schema.ExclusiveMaximum && v == hi) {
+			return nil, false
+		}
+		return v, true
+	}
+	kLo, kHi := math.Ceil(lo/step), math.Floor(hi/step)
+	if schema.ExclusiveMinimum && kLo*step == lo {
+		kLo++
+	}
+	if schema.ExclusiveMaximum && kHi*step == hi {
+		kHi--
+	}
+	if kLo > kHi || math.IsInf(kLo, 0) || math.IsInf(kHi, 0) {
+		return nil, false
+	}
+	//the span is limited above, but a small step can still make too many multiples to count in an int64
+	if kHi-kLo > 1<<40 {
+		kHi = kLo + 1<<40
+	}
+	k := kLo + float64(this.rand.Int63n(int64(kHi-kLo)+1))
+	//removes the rounding errors of multiplying by a fraction, like 3 * 0.1
+	v, err := strconv.ParseFloat(strconv.FormatFloat(k*step, 'g', 12, 64), 64)
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyz"
+
+func (this *Generator) string(schema String) (interface{}, bool) {
+	min := int(schema.MinLength)
+	max := min + 8
+	if schema.MaxLength != nil {
+		max = int(*schema.MaxLength)
+	}
+	if min > max {
+		return nil, false
+	}
+	if schema.Pattern != nil {
+		re, err := syntax.Parse(*schema.Pattern, syntax.Perl)
+		if err != nil {
+			return nil, false
+		}
+		rs, ok := this.regex(re.Simplify())
+		if !ok {
+			return nil, false
+		}
+		//letters are added to reach the minimum length, which only fails the evaluation if the pattern is anchored at the end
+		for len(rs) < min {
+			rs = append(rs, rune(letters[this.rand.Intn(len(letters))]))
+		}
+		return string(rs), true
+	}
+	n := min + this.rand.Intn(max-min+1)
+	rs := make([]rune, n)
+	for i := range rs {
+		rs[i] = rune(letters[this.rand.Intn(len(letters))])
+	}
+	return string(rs), true
+}
+
+//regex generates a string that matches the regular expression.
+func (this *Generator) regex(re *syntax.Regexp) ([]rune, bool) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return nil, false
+	case syntax.OpLiteral:
+		return append([]rune{}, re.Rune...), true
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return nil, false
+		}
+		i := this.rand.Intn(len(re.Rune)/2) * 2
+		lo, hi := re.Rune[i], re.Rune[i+1]
+		if hi-lo > 0xff {
+			hi = lo + 0xff
+		}
+		return []rune{lo + rune(this.rand.Intn(int(hi-lo)+1))}, true
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []rune{rune(letters[this.rand.Intn(len(letters))])}, true
+	case syntax.OpCapture:
+		return this.regex(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := 0, 3
+		switch re.Op {
+		case syntax.OpPlus:
+			min = 1
+		case syntax.OpQuest:
+			max = 1
+		case syntax.OpRepeat:
+			min, max = re.Min, re.Max
+			if max < 0 {
+				max = min + 3
+			}
+		}
+		rs := []rune{}
+		for n := min + this.rand.Intn(max-min+1); n > 0; n-- {
+			sub, ok := this.regex(re.Sub[0])
+			if !ok {
+				return nil, false
+			}
+			rs = append(rs, sub...)
+		}
+		return rs, true
+	case syntax.OpConcat:
+		rs := []rune{}
+		for _, sub := range re.Sub {
+			s, ok := this.regex(sub)
+			if !ok {
+				return nil, false
+			}
+			rs = append(rs, s...)
+		}
+		return rs, true
+	case syntax.OpAlternate:
+		return this.regex(re.Sub[this.rand.Intn(len(re.Sub))])
+	}
+	//empty matches, anchors and word boundaries
+	return []rune{}, true
+}
+
+func (this *Generator) object(schema Object, depth int) (interface{}, bool) {
+	object := make(map[string]interface{})
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(schema.Properties)+len(schema.Required))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	optional := []string{}
+	for _, name := range names {
+		if !required[name] && (depth >= this.MaxDepth || this.rand.Intn(2) == 0) {
+			optional = append(optional, name)
+			continue
+		}
+		v, ok := this.property(schema, name, depth)
+		if !ok {
+			return nil, false
+		}
+		object[name] = v
+	}
+	min := int(schema.MinProperties)
+	for i := 0; len(object) < min; i++ {
+		name := ""
+		if i < len(optional) {
+			name = optional[i]
+		} else if schema.AdditionalProperties == nil || schema.AdditionalProperties.Bool == nil || *schema.AdditionalProperties.Bool {
+			name = "p" + strconv.Itoa(i)
+		} else {
+			return nil, false
+		}
+		v, ok := this.property(schema, name, depth)
+		if !ok {
+			return nil, false
+		}
+		object[name] = v
+	}
+	return object, true
+}
+
+func (this *Generator) property(schema Object, name string, depth int) (interface{}, bool) {
+	if s, ok := schema.Properties[name]; ok {
+		return this.generate(s, depth+1)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Type != "" && schema.AdditionalProperties.Type != TypeUnknown {
+		return this.generate(typeSchema(schema.AdditionalProperties.Type), depth+1)
+	}
+	return this.generate(&Schema{}, depth+1)
+}
+
+func (this *Generator) array(schema Array, depth int) (interface{}, bool) {
+	min := int(schema.MinItems)
+	max := min + 3
+	if schema.MaxItems != nil {
+		max = int(*schema.MaxItems)
+	}
+	var tuple []*Schema
+	item := &Schema{}
+	if schema.Items != nil {
+		if schema.Items.Object != nil {
+			item = schema.Items.Object
+		}
+		tuple = schema.Items.Array
+	}
+	if tuple != nil && schema.AdditionalItems != nil {
+		if schema.AdditionalItems.Bool != nil && !*schema.AdditionalItems.Bool {
+			if len(tuple) < max {
+				max = len(tuple)
+			}
+		} else if schema.AdditionalItems.Type != "" && schema.AdditionalItems.Type != TypeUnknown {
+			item = typeSchema(schema.AdditionalItems.Type)
+		}
+	}
+	if min > max {
+		return nil, false
+	}
+	n := min
+	if depth < this.MaxDepth {
+		n += this.rand.Intn(max - min + 1)
+	}
+	array := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		s := item
+		if i < len(tuple) {
+			s = tuple[i]
+		}
+		v, ok := this.uniqueItem(s, array, schema.UniqueItems, depth)
+		if !ok {
+			return nil, false
+		}
+		array = append(array, v)
+	}
+	return array, true
+}
+
+func (this *Generator) uniqueItem(schema *Schema, array []interface{}, unique bool, depth int) (interface{}, bool) {
+	for attempt := 0; attempt < 10; attempt++ {
+		v, ok := this.generate(schema, depth+1)
+		if !ok {
+			continue
+		}
+		if !unique {
+			return v, true
+		}
+		duplicate := false
+		for _, other := range array {
+			if equalInstance(v, other) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			return v, true
+		}
+	}
+	return nil, false
+}
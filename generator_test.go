@@ -0,0 +1,142 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	tests := buildTests(t)
+	done := map[string]bool{}
+	g := NewGenerator(1)
+	for _, test := range tests {
+		if skippingFile[test.Filename] || done[string(test.Schema)] {
+			continue
+		}
+		done[string(test.Schema)] = true
+		schema, err := ParseSchema(test.Schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := CompileSchema(schema)
+		if err != nil {
+			continue
+		}
+		for i := 0; i < 10; i++ {
+			data, err := g.GenerateJson(schema)
+			if err != nil {
+				t.Errorf("--- FAIL: %v: %v", test, err)
+				break
+			}
+			if err := v.Validate(data); err != nil {
+				t.Errorf("--- FAIL: %v: generated %s: %v", test, data, err)
+			}
+		}
+	}
+}
+
+func TestGeneratorKeywords(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "pattern": "^[a-f0-9]{8}$"},
+			"count": {"type": "integer", "minimum": 10, "exclusiveMaximum": true, "maximum": 20, "multipleOf": 3},
+			"price": {"type": "number", "minimum": 0, "multipleOf": 0.25},
+			"small": {"type": "number", "multipleOf": 1e-9, "minimum": 0, "maximum": 1e12},
+			"name": {"type": "string", "minLength": 2, "maxLength": 4},
+			"either": {"oneOf": [{"type": "boolean"}, {"type": "null"}]}
+		},
+		"required": ["id", "count", "price", "small", "name", "either"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := CompileSchema(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGenerator(42)
+	for i := 0; i < 100; i++ {
+		instance, err := g.Generate(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if object := instance.(map[string]interface{}); len(object) != 6 {
+			t.Fatalf("expected all required properties, but got %#v", object)
+		}
+		data, err := json.Marshal(instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.Validate(data); err != nil {
+			t.Fatalf("generated invalid %s: %v", data, err)
+		}
+	}
+}
+
+//TestGeneratorUntranslatable generates instances for keywords that cannot be translated,
+//which are checked with the diagnostic evaluation instead.
+func TestGeneratorUntranslatable(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"kind": {"enum": ["a", "b"]},
+			"tags": {"type": "array", "items": {"type": "string", "maxLength": 1}, "minItems": 2, "uniqueItems": true}
+		},
+		"required": ["kind", "tags"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompileSchema(schema); err == nil {
+		t.Fatalf("expected a translate error")
+	}
+	g := NewGenerator(42)
+	for i := 0; i < 100; i++ {
+		instance, err := g.Generate(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := evalSchema(schema, instance, "", ""); !e.valid {
+			t.Fatalf("generated invalid %#v: %v", instance, e.failures())
+		}
+		object := instance.(map[string]interface{})
+		if tags := object["tags"].([]interface{}); len(tags) < 2 || equalInstance(tags[0], tags[1]) {
+			t.Fatalf("expected unique tags, but got %v", tags)
+		}
+	}
+}
+
+func TestGeneratorSeed(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"properties": {"a": {"type": "string"}, "b": {"type": "number"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewGenerator(7).GenerateJson(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewGenerator(7).GenerateJson(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatalf("expected the same instance for the same seed, but got %s and %s", a, b)
+	}
+}
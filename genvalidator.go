@@ -0,0 +1,392 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+//GenerateValidator generates gofmted Go source, in the package, with a function with the name:
+//  func name(data []byte) (bool, error)
+//that returns whether the json data is valid against the schema.
+//The generated code only depends on the standard library, so no schema is translated or interpreted at runtime.
+//
+//The generated function has the same semantics as validating with the relapse grammar from TranslateDraft4,
+//including where the translation differs from the specification:
+//  - the numeric, string, object and instance keywords are mutually exclusive and checked in that order;
+//  - only the first of allOf, anyOf, oneOf and not is checked;
+//  - arrays and objects are not distinguished by type;
+//  - numeric and string keywords reject arrays and objects.
+//A schema that cannot be translated returns the same error as TranslateDraft4.
+func GenerateValidator(schema *Schema, pkg, name string) ([]byte, error) {
+	if _, err := TranslateDraft4(schema); err != nil {
+		return nil, err
+	}
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("%q is not a valid function name", name)
+	}
+	rs := []rune(name)
+	rs[0] = unicode.ToLower(rs[0])
+	g := &validatorGen{prefix: string(rs)}
+	main, err := g.schema(schema)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by jsonschema.GenerateValidator. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	fmt.Fprintf(buf, "import (\n\t\"bytes\"\n\t\"encoding/json\"\n\t\"errors\"\n\t\"io\"\n")
+	if len(g.regexps) > 0 {
+		fmt.Fprintf(buf, "\t\"regexp\"\n")
+	}
+	fmt.Fprintf(buf, "\t\"strconv\"\n\t\"unicode/utf8\"\n)\n\n")
+	fmt.Fprintf(buf, "// %s returns whether the json data is valid.\n", name)
+	fmt.Fprintf(buf, "func %s(data []byte) (bool, error) {\n", name)
+	fmt.Fprintf(buf, "\tdec := json.NewDecoder(bytes.NewReader(data))\n\tdec.UseNumber()\n")
+	fmt.Fprintf(buf, "\tvar v interface{}\n\tif err := dec.Decode(&v); err != nil {\n\t\treturn false, err\n\t}\n")
+	fmt.Fprintf(buf, "\tif _, err := dec.Token(); err != io.EOF {\n\t\treturn false, errors.New(\"unexpected data after the json value\")\n\t}\n")
+	fmt.Fprintf(buf, "\treturn %s(v), nil\n}\n", main)
+	for i, re := range g.regexps {
+		fmt.Fprintf(buf, "\nvar %sRegexp%d = regexp.MustCompile(%s)\n", g.prefix, i, strconv.Quote(re))
+	}
+	for _, f := range g.funcs {
+		buf.WriteString("\n")
+		buf.WriteString(f)
+	}
+	buf.WriteString(fmt.Sprintf(validatorHelpers, g.prefix))
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid go source: %v\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+//validatorHelpers are the functions that the generated schema functions call, where %[1]s is the prefix.
+//They mirror the funcs that the translated grammar uses, like Integer, Number and MultipleOf.
+const validatorHelpers = `
+//%[1]sLeaf returns whether the value is not an array or object.
+func %[1]sLeaf(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+func %[1]sTree(v interface{}) bool {
+	return !%[1]sLeaf(v)
+}
+
+func %[1]sNull(v interface{}) bool {
+	return v == nil
+}
+
+func %[1]sBool(v interface{}) bool {
+	_, ok := v.(bool)
+	return ok
+}
+
+func %[1]sString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func %[1]sInteger(v interface{}) bool {
+	_, ok := %[1]sIntegerValue(v)
+	return ok
+}
+
+func %[1]sIntegerValue(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return float64(u), true
+	}
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+func %[1]sNumber(v interface{}) bool {
+	_, ok := %[1]sNumberValue(v)
+	return ok
+}
+
+func %[1]sNumberValue(v interface{}) (float64, bool) {
+	if i, ok := %[1]sIntegerValue(v); ok {
+		return i, true
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	return f, err == nil
+}
+
+func %[1]sMultipleOf(n, d float64) bool {
+	v := n / d
+	return v == float64(int64(v)) || v == float64(uint64(v))
+}
+
+func %[1]sLength(s string) int {
+	return utf8.RuneCountInString(s)
+}
+`
+
+type validatorGen struct {
+	prefix  string
+	funcs   []string
+	regexps []string
+}
+
+//typeFuncs are the names of the helpers that check each type.
+var typeFuncs = map[SimpleType]string{
+	TypeArray:   "Tree",
+	TypeObject:  "Tree",
+	TypeBoolean: "Bool",
+	TypeInteger: "Integer",
+	TypeNull:    "Null",
+	TypeNumber:  "Number",
+	TypeString:  "String",
+}
+
+func floatLiteral(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+//schema declares a function for the schema, as it is translated by translate, and returns its name.
+func (this *validatorGen) schema(schema *Schema) (string, error) {
+	name := this.prefix + "Schema" + strconv.Itoa(len(this.funcs))
+	i := len(this.funcs)
+	this.funcs = append(this.funcs, "")
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "func %s(v interface{}) bool {\n", name)
+	if schema.Type != nil {
+		checks := make([]string, len(*schema.Type))
+		for i, t := range *schema.Type {
+			checks[i] = this.prefix + typeFuncs[t] + "(v)"
+		}
+		fmt.Fprintf(buf, "if !(%s) {\nreturn false\n}\n", joinOr(checks))
+	}
+	if err := this.one(buf, schema); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(buf, "return true\n}\n")
+	this.funcs[i] = buf.String()
+	return name, nil
+}
+
+func joinOr(checks []string) string {
+	s := checks[0]
+	for _, c := range checks[1:] {
+		s += " || " + c
+	}
+	return s
+}
+
+//one writes the checks of translateOne.
+func (this *validatorGen) one(buf *bytes.Buffer, schema *Schema) error {
+	switch {
+	case schema.HasNumericConstraints():
+		this.numeric(buf, schema.Numeric)
+	case schema.HasStringConstraints():
+		return this.string(buf, schema.String)
+	case schema.HasObjectConstraints():
+		return this.object(buf, schema)
+	case schema.HasInstanceConstraints():
+		return this.instance(buf, schema.Instance)
+	}
+	return nil
+}
+
+func (this *validatorGen) numeric(buf *bytes.Buffer, schema Numeric) {
+	fmt.Fprintf(buf, "if !%sLeaf(v) {\nreturn false\n}\n", this.prefix)
+	fmt.Fprintf(buf, "if n, ok := %sNumberValue(v); ok {\n", this.prefix)
+	if schema.MultipleOf != nil {
+		fmt.Fprintf(buf, "if !%sMultipleOf(n, %s) {\nreturn false\n}\n", this.prefix, floatLiteral(*schema.MultipleOf))
+	}
+	if schema.Maximum != nil {
+		op := "<="
+		if schema.ExclusiveMaximum {
+			op = "<"
+		}
+		fmt.Fprintf(buf, "if !(n %s %s) {\nreturn false\n}\n", op, floatLiteral(*schema.Maximum))
+	}
+	if schema.Minimum != nil {
+		op := ">="
+		if schema.ExclusiveMinimum {
+			op = ">"
+		}
+		fmt.Fprintf(buf, "if !(n %s %s) {\nreturn false\n}\n", op, floatLiteral(*schema.Minimum))
+	}
+	fmt.Fprintf(buf, "}\n")
+}
+
+func (this *validatorGen) string(buf *bytes.Buffer, schema String) error {
+	fmt.Fprintf(buf, "if !%sLeaf(v) {\nreturn false\n}\n", this.prefix)
+	fmt.Fprintf(buf, "if s, ok := v.(string); ok {\n")
+	if schema.MaxLength != nil {
+		fmt.Fprintf(buf, "if %sLength(s) > %d {\nreturn false\n}\n", this.prefix, *schema.MaxLength)
+	}
+	if schema.MinLength > 0 {
+		fmt.Fprintf(buf, "if %sLength(s) < %d {\nreturn false\n}\n", this.prefix, schema.MinLength)
+	}
+	if schema.Pattern != nil {
+		if _, err := regexp.Compile(*schema.Pattern); err != nil {
+			return notSupported("pattern", "%v", err)
+		}
+		fmt.Fprintf(buf, "if !%sRegexp%d.MatchString(s) {\nreturn false\n}\n", this.prefix, len(this.regexps))
+		this.regexps = append(this.regexps, *schema.Pattern)
+	}
+	fmt.Fprintf(buf, "}\n")
+	return nil
+}
+
+//object writes the checks of the interleaving of properties that translateObject returns.
+//Array items do not have string names, so they never match a property.
+func (this *validatorGen) object(buf *bytes.Buffer, schema *Schema) error {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; ok {
+			required[name] = true
+		}
+	}
+	//additional is the check of the values of the other members, where the empty string means any value.
+	additional := ""
+	forbidden := false
+	if a := schema.AdditionalProperties; a != nil {
+		if a.Bool != nil && !*a.Bool {
+			forbidden = true
+		} else if a.Type != "" && a.Type != TypeUnknown {
+			additional = this.prefix + typeFuncs[a.Type] + "(child)"
+		}
+	}
+	props := make(map[string]string, len(names))
+	for _, name := range names {
+		f, err := this.schema(schema.Properties[name])
+		if err != nil {
+			return within(err, "properties", name)
+		}
+		props[name] = f
+	}
+	cases := &bytes.Buffer{}
+	fmt.Fprintf(cases, "case map[string]interface{}:\n")
+	if len(names) > 0 {
+		fmt.Fprintf(cases, "for name, child := range o {\n")
+		fmt.Fprintf(cases, "switch name {\n")
+		for _, name := range names {
+			check := props[name] + "(child)"
+			if len(additional) > 0 && !required[name] {
+				//an optional member can also be matched by additionalProperties.
+				check += " || " + additional
+			}
+			fmt.Fprintf(cases, "case %s:\nif !(%s) {\nreturn false\n}\n", strconv.Quote(name), check)
+		}
+		fmt.Fprintf(cases, "default:\n")
+		if forbidden {
+			fmt.Fprintf(cases, "return false\n")
+		} else if len(additional) > 0 {
+			fmt.Fprintf(cases, "if !%s {\nreturn false\n}\n", additional)
+		}
+		fmt.Fprintf(cases, "}\n}\n")
+	} else if forbidden {
+		fmt.Fprintf(cases, "if len(o) > 0 {\nreturn false\n}\n")
+	} else if len(additional) > 0 {
+		fmt.Fprintf(cases, "for _, child := range o {\nif !%s {\nreturn false\n}\n}\n", additional)
+	}
+	uses := len(names) > 0 || forbidden || len(additional) > 0
+	for _, name := range names {
+		if required[name] {
+			fmt.Fprintf(cases, "if _, ok := o[%s]; !ok {\nreturn false\n}\n", strconv.Quote(name))
+		}
+	}
+	fmt.Fprintf(cases, "case []interface{}:\n")
+	if len(required) > 0 {
+		fmt.Fprintf(cases, "return false\n")
+	} else if forbidden {
+		fmt.Fprintf(cases, "if len(o) > 0 {\nreturn false\n}\n")
+	} else if len(additional) > 0 {
+		fmt.Fprintf(cases, "for _, child := range o {\nif !%s {\nreturn false\n}\n}\n", additional)
+	}
+	if uses {
+		//only an interleaving that can match anything matches a value.
+		fmt.Fprintf(cases, "default:\nreturn false\n")
+	}
+	if uses {
+		fmt.Fprintf(buf, "switch o := v.(type) {\n%s}\n", cases.String())
+	}
+	return nil
+}
+
+//instance writes the checks of translateInstance, which only translates the first keyword that is set.
+func (this *validatorGen) instance(buf *bytes.Buffer, schema Instance) error {
+	var funcs []string
+	schemas := func(keyword string, ss []*Schema) error {
+		for i, s := range ss {
+			f, err := this.schema(s)
+			if err != nil {
+				return within(err, keyword, strconv.Itoa(i))
+			}
+			funcs = append(funcs, f+"(v)")
+		}
+		return nil
+	}
+	switch {
+	case len(schema.AllOf) > 0:
+		if err := schemas("allOf", schema.AllOf); err != nil {
+			return err
+		}
+		for _, f := range funcs {
+			fmt.Fprintf(buf, "if !%s {\nreturn false\n}\n", f)
+		}
+	case len(schema.AnyOf) > 0:
+		if err := schemas("anyOf", schema.AnyOf); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "if !(%s) {\nreturn false\n}\n", joinOr(funcs))
+	case len(schema.OneOf) > 0:
+		if err := schemas("oneOf", schema.OneOf); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "matches := 0\n")
+		for _, f := range funcs {
+			fmt.Fprintf(buf, "if %s {\nmatches++\n}\n", f)
+		}
+		fmt.Fprintf(buf, "if matches != 1 {\nreturn false\n}\n")
+	case schema.Not != nil:
+		f, err := this.schema(schema.Not)
+		if err != nil {
+			return within(err, "not")
+		}
+		fmt.Fprintf(buf, "if %s(v) {\nreturn false\n}\n", f)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateValidator(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "pattern": "^[a-z]+$"},
+			"age": {"type": "integer", "minimum": 0}
+		},
+		"required": ["name"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateValidator(schema, "people", "ValidatePerson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package people", "func ValidatePerson(data []byte) (bool, error)", `regexp.MustCompile("^[a-z]+$")`} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Fatalf("expected %q in\n%s", want, src)
+		}
+	}
+	if _, err := GenerateValidator(schema, "people", "not a name"); err == nil {
+		t.Fatalf("expected an error for an invalid function name")
+	}
+}
+
+func TestGenerateValidatorNotSupported(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"properties": {"a": {"enum": [1, 2]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = GenerateValidator(schema, "p", "validate")
+	terr, ok := err.(*TranslateError)
+	if !ok {
+		t.Fatalf("expected a *TranslateError, but got %v", err)
+	}
+	if terr.SchemaPointer != "/properties/a/enum" {
+		t.Fatalf("expected the location of enum, but got %s", terr.SchemaPointer)
+	}
+}
+
+type differentialCase struct {
+	Validator   int             `json:"validator"`
+	Data        json.RawMessage `json:"data"`
+	description string
+	interpreted bool
+}
+
+const differentialMain = `package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+var validators = []func([]byte) (bool, error){%s}
+
+func main() {
+	data, err := ioutil.ReadFile(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+	var cases []struct {
+		Validator int
+		Data      json.RawMessage
+	}
+	if err := json.Unmarshal(data, &cases); err != nil {
+		panic(err)
+	}
+	results := make([]bool, len(cases))
+	for i, c := range cases {
+		results[i], err = validators[c.Validator](c.Data)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+		panic(err)
+	}
+}
+`
+
+//TestGenerateValidatorDifferential generates a validator for every draft4 schema in the test suite that can be translated,
+//runs the generated code with the go tool and compares its results to the interpreted grammar.
+func TestGenerateValidatorDifferential(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go tool")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool is not available")
+	}
+	dir, err := ioutil.TempDir("", "jsonschema-differential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module differential\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(suitePath, "draft4", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []*differentialCase
+	var names []string
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schemaTests []*SchemaTest
+		if err := json.Unmarshal(content, &schemaTests); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, schemaTest := range schemaTests {
+			data, err := json.Marshal(schemaTest.Schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := Compile(data)
+			if err != nil {
+				continue
+			}
+			name := fmt.Sprintf("validate%d", len(names))
+			src, err := GenerateValidator(v.Schema(), "main", name)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", file, schemaTest.Description, err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, test := range schemaTest.Tests {
+				data, err := json.Marshal(test.Data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = v.Validate(data)
+				if err != nil && !errors.Is(err, ErrInvalid) {
+					t.Fatalf("%s: %s: %s: %v", file, schemaTest.Description, test.Description, err)
+				}
+				cases = append(cases, &differentialCase{
+					Validator:   len(names),
+					Data:        data,
+					description: filepath.Base(file) + ": " + schemaTest.Description + ": " + test.Description,
+					interpreted: err == nil,
+				})
+			}
+			names = append(names, name)
+		}
+	}
+	main := fmt.Sprintf(differentialMain, strings.Join(names, ", "))
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "cases.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(gotool, "run", ".", "cases.json")
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr.String())
+	}
+	var generated []bool
+	if err := json.Unmarshal(out, &generated); err != nil {
+		t.Fatal(err)
+	}
+	if len(generated) != len(cases) {
+		t.Fatalf("expected %d results, but got %d", len(cases), len(generated))
+	}
+	for i, c := range cases {
+		if generated[i] != c.interpreted {
+			t.Errorf("%s: generated %v, but interpreted %v", c.description, generated[i], c.interpreted)
+		}
+	}
+	t.Logf("compared %d tests of %d schemas", len(cases), len(names))
+}
@@ -0,0 +1,435 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//GoTypes generates gofmted Go source, in the package, with a type declaration for the schema, with the name,
+//and for each of its definitions.
+//
+//Objects with properties are structs with json tags.
+//Properties that are not required are pointers, or slices and maps, with the omitempty option.
+//String enums are named string types with a constant for each value.
+//An allOf of objects and references to objects is a struct that embeds the referenced types.
+//Schemas that cannot be represented by a Go type, like an anyOf, are interface{}.
+//Only references to the schema itself and its definitions are supported, see InlineRefs for other references.
+func GoTypes(schema *Schema, pkg, name string) ([]byte, error) {
+	g := &goGen{
+		names: make(map[string]bool),
+		defs:  make(map[string]string),
+		open:  make(map[string]bool),
+	}
+	rootName := g.name(name)
+	g.defs["#"] = rootName
+	defNames := make([]string, 0, len(schema.Definitions))
+	for def := range schema.Definitions {
+		defNames = append(defNames, def)
+	}
+	sort.Strings(defNames)
+	for _, def := range defNames {
+		g.defs["#/definitions/"+def] = g.name(def)
+	}
+	if err := g.declare(rootName, schema); err != nil {
+		return nil, err
+	}
+	for _, def := range defNames {
+		if err := g.declare(g.defs["#/definitions/"+def], schema.Definitions[def]); err != nil {
+			return nil, fmt.Errorf("definitions/%s: %v", def, err)
+		}
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by jsonschema.GoTypes. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n", pkg)
+	for _, decl := range g.decls {
+		buf.WriteString("\n")
+		buf.WriteString(decl)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid go source: %v\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+type goGen struct {
+	//names are the type names that are already declared.
+	names map[string]bool
+	//defs are the type names of the references.
+	defs map[string]string
+	//open are the structs that are being declared.
+	open  map[string]bool
+	decls []string
+}
+
+//initialisms are written in capitals, as in golint.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+//exported returns an exported Go identifier for the json name.
+func exported(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	buf := &bytes.Buffer{}
+	for _, part := range parts {
+		if initialisms[strings.ToUpper(part)] {
+			buf.WriteString(strings.ToUpper(part))
+			continue
+		}
+		rs := []rune(part)
+		rs[0] = unicode.ToUpper(rs[0])
+		buf.WriteString(string(rs))
+	}
+	name := buf.String()
+	if len(name) == 0 {
+		return "X"
+	}
+	if r := []rune(name)[0]; !unicode.IsLetter(r) || !unicode.IsUpper(r) {
+		name = "X" + name
+	}
+	return name
+}
+
+//name reserves a new type name that is based on the json name.
+func (this *goGen) name(s string) string {
+	name := exported(s)
+	unique := name
+	for i := 2; this.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	this.names[unique] = true
+	return unique
+}
+
+//definitionRef unescapes a reference to a definition, so that it can be looked up by the name of the definition.
+func definitionRef(ref string) (string, error) {
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return ref, nil
+	}
+	def, err := url.PathUnescape(strings.TrimPrefix(ref, "#/definitions/"))
+	if err != nil {
+		return "", err
+	}
+	def = strings.Replace(def, "~1", "/", -1)
+	def = strings.Replace(def, "~0", "~", -1)
+	return "#/definitions/" + def, nil
+}
+
+//ref returns the type name that the reference refers to.
+func (this *goGen) ref(ref string) (string, error) {
+	ref, err := definitionRef(ref)
+	if err != nil {
+		return "", err
+	}
+	name, ok := this.defs[ref]
+	if !ok {
+		return "", fmt.Errorf("$ref %s is not supported", ref)
+	}
+	return name, nil
+}
+
+func comment(buf *bytes.Buffer, name string, schema *Schema) {
+	text := schema.Description
+	if len(text) == 0 {
+		text = schema.Title
+	}
+	if len(text) == 0 {
+		return
+	}
+	for i, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if i == 0 && len(name) > 0 {
+			r, size := utf8.DecodeRuneInString(line)
+			line = name + " is " + string(unicode.ToLower(r)) + line[size:]
+		}
+		fmt.Fprintf(buf, "// %s\n", line)
+	}
+}
+
+//declare declares a named type for the schema.
+func (this *goGen) declare(name string, schema *Schema) error {
+	buf := &bytes.Buffer{}
+	comment(buf, name, schema)
+	if enum, ok := stringEnum(schema); ok {
+		fmt.Fprintf(buf, "type %s string\n\nconst (\n", name)
+		consts := make(map[string]bool)
+		for _, value := range enum {
+			c := name + exported(value)
+			for i := 2; consts[c] || this.names[c]; i++ {
+				c = name + exported(value) + strconv.Itoa(i)
+			}
+			consts[c] = true
+			fmt.Fprintf(buf, "\t%s %s = %s\n", c, name, strconv.Quote(value))
+		}
+		fmt.Fprintf(buf, ")\n")
+		for c := range consts {
+			this.names[c] = true
+		}
+		this.decls = append(this.decls, buf.String())
+		return nil
+	}
+	if isStruct(schema) {
+		//declare the struct before its nested types, so that it comes first.
+		i := len(this.decls)
+		this.decls = append(this.decls, "")
+		this.open[name] = true
+		body, err := this.structBody(name, schema)
+		delete(this.open, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "type %s %s\n", name, body)
+		this.decls[i] = buf.String()
+		return nil
+	}
+	typ, err := this.goType(name, schema)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "type %s %s\n", name, typ)
+	this.decls = append(this.decls, buf.String())
+	return nil
+}
+
+//stringEnum returns the values of an enum of strings.
+func stringEnum(schema *Schema) ([]string, bool) {
+	if len(schema.Enum) == 0 || schema.Type == nil || !schema.Type.Single() || (*schema.Type)[0] != TypeString {
+		return nil, false
+	}
+	values := make([]string, len(schema.Enum))
+	for i, v := range schema.Enum {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		values[i] = s
+	}
+	return values, true
+}
+
+//isStruct returns whether the schema is an object with properties or an allOf that can be a struct.
+func isStruct(schema *Schema) bool {
+	if len(schema.Ref) > 0 {
+		return false
+	}
+	if len(schema.Properties) > 0 {
+		return true
+	}
+	if len(schema.AllOf) == 0 {
+		return false
+	}
+	for _, s := range schema.AllOf {
+		if len(s.Ref) == 0 && !isStruct(s) {
+			return false
+		}
+	}
+	return true
+}
+
+//nonNull returns the type of the schema without null and whether null is allowed.
+func nonNull(schema *Schema) (SimpleType, bool, bool) {
+	if schema.Type == nil {
+		return "", false, false
+	}
+	var types []SimpleType
+	null := false
+	for _, t := range *schema.Type {
+		if t == TypeNull {
+			null = true
+		} else {
+			types = append(types, t)
+		}
+	}
+	if len(types) != 1 {
+		return "", null, false
+	}
+	return types[0], null, true
+}
+
+//orNull returns the other schema of an anyOf or oneOf that is only a schema or null.
+func orNull(schema *Schema) (*Schema, bool) {
+	of := schema.AnyOf
+	if len(of) == 0 {
+		of = schema.OneOf
+	}
+	if len(of) != 2 {
+		return nil, false
+	}
+	for i, s := range of {
+		if s.Type != nil && len(*s.Type) == 1 && (*s.Type)[0] == TypeNull {
+			return of[1-i], true
+		}
+	}
+	return nil, false
+}
+
+//goType returns the Go type of the schema, where name is the name of a type that is declared for it, if it is needed.
+func (this *goGen) goType(name string, schema *Schema) (string, error) {
+	if len(schema.Ref) > 0 {
+		return this.ref(schema.Ref)
+	}
+	if _, ok := stringEnum(schema); ok || isStruct(schema) {
+		name = this.name(name)
+		if err := this.declare(name, schema); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	if other, ok := orNull(schema); ok {
+		typ, err := this.goType(name, other)
+		if err != nil || typ == "interface{}" || strings.HasPrefix(typ, "*") ||
+			strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") {
+			return typ, err
+		}
+		return "*" + typ, nil
+	}
+	typ, null, ok := nonNull(schema)
+	if !ok {
+		return "interface{}", nil
+	}
+	goType := ""
+	switch typ {
+	case TypeString:
+		goType = "string"
+	case TypeInteger:
+		goType = "int64"
+	case TypeNumber:
+		goType = "float64"
+	case TypeBoolean:
+		goType = "bool"
+	case TypeArray:
+		items := "interface{}"
+		if schema.Items != nil && schema.Items.Object != nil {
+			var err error
+			items, err = this.goType(name+"Item", schema.Items.Object)
+			if err != nil {
+				return "", err
+			}
+		}
+		return "[]" + items, nil
+	case TypeObject:
+		value := "interface{}"
+		if a := schema.AdditionalProperties; a != nil && a.Bool == nil {
+			var err error
+			value, err = this.goType(name+"Value", &Schema{Type: &Type{a.Type}})
+			if err != nil {
+				return "", err
+			}
+		}
+		return "map[string]" + value, nil
+	default:
+		return "", fmt.Errorf("type %s is not supported", typ)
+	}
+	if null {
+		return "*" + goType, nil
+	}
+	return goType, nil
+}
+
+func (this *goGen) structBody(name string, schema *Schema) (string, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("struct {\n")
+	for _, s := range schema.AllOf {
+		if len(s.Ref) == 0 {
+			continue
+		}
+		embedded, err := this.ref(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(buf, "\t%s\n", embedded)
+	}
+	if err := this.fields(buf, name, schema); err != nil {
+		return "", err
+	}
+	for _, s := range schema.AllOf {
+		if len(s.Ref) > 0 {
+			continue
+		}
+		if err := this.fields(buf, name, s); err != nil {
+			return "", err
+		}
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+//validTag returns whether encoding/json accepts the name in a struct tag,
+//which, besides letters and digits, only allows some punctuation and never a comma, quote, backslash or backquote.
+func validTag(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, c := range name {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c) {
+			return false
+		}
+	}
+	return true
+}
+
+//fields writes a field for each property of the schema, sorted by name.
+func (this *goGen) fields(buf *bytes.Buffer, name string, schema *Schema) error {
+	required := make(map[string]bool)
+	for _, r := range schema.Required {
+		required[r] = true
+	}
+	props := make([]string, 0, len(schema.Properties))
+	for prop := range schema.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	fieldNames := make(map[string]bool)
+	for _, prop := range props {
+		if !validTag(prop) {
+			return fmt.Errorf("properties/%s: the property name %q cannot be a json struct tag", prop, prop)
+		}
+		p := schema.Properties[prop]
+		fieldName := exported(prop)
+		for i := 2; fieldNames[fieldName]; i++ {
+			fieldName = exported(prop) + strconv.Itoa(i)
+		}
+		fieldNames[fieldName] = true
+		typ, err := this.goType(name+exported(prop), p)
+		if err != nil {
+			return fmt.Errorf("properties/%s: %v", prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+			if !strings.HasPrefix(typ, "*") && !strings.HasPrefix(typ, "[]") &&
+				!strings.HasPrefix(typ, "map[") && typ != "interface{}" {
+				typ = "*" + typ
+			}
+		} else if len(p.Ref) > 0 && this.open[typ] {
+			//a required value of a type that contains it would be infinitely large.
+			typ = "*" + typ
+		}
+		comment(buf, fieldName, p)
+		fmt.Fprintf(buf, "\t%s %s `json:%s`\n", fieldName, typ, strconv.Quote(tag))
+	}
+	return nil
+}
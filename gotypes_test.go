@@ -0,0 +1,131 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoTypes(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"description": "A person with an address.",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string", "description": "The full name."},
+			"kind": {"type": "string", "enum": ["admin", "user-account"]},
+			"home": {"$ref": "#/definitions/address"},
+			"work": {"anyOf": [{"$ref": "#/definitions/address"}, {"type": "null"}]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"scores": {"type": "object", "additionalProperties": {"type": "number"}},
+			"parent": {"$ref": "#"},
+			"size": {"type": "object", "properties": {"width": {"type": "number"}}, "required": ["width"]},
+			"extra": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		},
+		"required": ["id", "name", "kind", "home", "parent"],
+		"definitions": {
+			"address": {
+				"type": "object",
+				"properties": {
+					"street": {"type": "string"},
+					"zip": {"type": ["string", "null"]}
+				},
+				"required": ["street", "zip"]
+			},
+			"employee": {
+				"allOf": [
+					{"$ref": "#"},
+					{"properties": {"salary": {"type": "number"}}}
+				]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GoTypes(schema, "people", "person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "// Code generated by jsonschema.GoTypes. DO NOT EDIT.\n\npackage people\n\n" +
+		"// Person is a person with an address.\n" +
+		"type Person struct {\n" +
+		"\tExtra interface{} `json:\"extra,omitempty\"`\n" +
+		"\tHome  Address     `json:\"home\"`\n" +
+		"\tID    int64       `json:\"id\"`\n" +
+		"\tKind  PersonKind  `json:\"kind\"`\n" +
+		"\t// Name is the full name.\n" +
+		"\tName   string             `json:\"name\"`\n" +
+		"\tParent *Person            `json:\"parent\"`\n" +
+		"\tScores map[string]float64 `json:\"scores,omitempty\"`\n" +
+		"\tSize   *PersonSize        `json:\"size,omitempty\"`\n" +
+		"\tTags   []string           `json:\"tags,omitempty\"`\n" +
+		"\tWork   *Address           `json:\"work,omitempty\"`\n" +
+		"}\n\n" +
+		"type PersonKind string\n\n" +
+		"const (\n" +
+		"\tPersonKindAdmin       PersonKind = \"admin\"\n" +
+		"\tPersonKindUserAccount PersonKind = \"user-account\"\n" +
+		")\n\n" +
+		"type PersonSize struct {\n" +
+		"\tWidth float64 `json:\"width\"`\n" +
+		"}\n\n" +
+		"type Address struct {\n" +
+		"\tStreet string  `json:\"street\"`\n" +
+		"\tZip    *string `json:\"zip\"`\n" +
+		"}\n\n" +
+		"type Employee struct {\n" +
+		"\tPerson\n" +
+		"\tSalary *float64 `json:\"salary,omitempty\"`\n" +
+		"}\n"
+	if string(src) != want {
+		t.Fatalf("expected\n%s\nbut got\n%s", want, src)
+	}
+}
+
+func TestGoTypesUnsupportedRef(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"type": "object", "properties": {"a": {"$ref": "other.json#"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GoTypes(schema, "p", "a"); err == nil {
+		t.Fatalf("expected an error for a reference to another file")
+	}
+}
+
+func TestGoTypesInvalidTag(t *testing.T) {
+	for _, name := range []string{"a,b", "a`b", `a"b`, ""} {
+		schema := &Schema{}
+		schema.Properties = map[string]*Schema{name: {}}
+		if _, err := GoTypes(schema, "p", "a"); err == nil {
+			t.Errorf("expected an error for the property %q", name)
+		}
+	}
+}
+
+func TestGoTypesComment(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"description": "Über alles"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GoTypes(schema, "p", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "// A is über alles\n") {
+		t.Fatalf("expected a lowercased comment, but got\n%s", src)
+	}
+}
@@ -0,0 +1,143 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+)
+
+//BodyValidator is an http.Handler that validates json request bodies before passing the requests on.
+//The Validator is chosen by the method, path and content type of the request.
+//Requests without a Validator are passed on without reading their bodies.
+//
+//A body that cannot be read or is not json is rejected with 400 Bad Request,
+//a body that is larger than MaxBodySize with 413 Request Entity Too Large and
+//a body that does not match the schema with 422 Unprocessable Entity.
+//If the body cannot be validated the response is 500 Internal Server Error.
+//All these responses are a json BodyError.
+//A valid body is passed on as the request body and can also be retrieved with RequestBody.
+//
+//Validators are safe for concurrent use, so the compiled grammar is reused across requests.
+type BodyValidator struct {
+	next   http.Handler
+	routes map[route]*Validator
+	//MaxBodySize limits the size of the request bodies that are validated, if it is larger than zero.
+	//Larger bodies are rejected with 413 Request Entity Too Large.
+	MaxBodySize int64
+}
+
+type route struct {
+	method      string
+	path        string
+	contentType string
+}
+
+//NewBodyValidator returns a BodyValidator that passes the requests on to next.
+func NewBodyValidator(next http.Handler) *BodyValidator {
+	return &BodyValidator{next: next, routes: make(map[route]*Validator)}
+}
+
+//Handle validates the bodies of requests with the method, url path and media type, like "application/json", with v.
+//An empty method or content type matches any method or content type.
+//Routes with a method and content type take precedence over routes without them.
+func (this *BodyValidator) Handle(method, path, contentType string, v *Validator) {
+	this.routes[route{method, path, contentType}] = v
+}
+
+func (this *BodyValidator) validator(r *http.Request) *Validator {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		contentType = ""
+	}
+	candidates := []route{
+		{r.Method, r.URL.Path, contentType},
+		{r.Method, r.URL.Path, ""},
+		{"", r.URL.Path, contentType},
+		{"", r.URL.Path, ""},
+	}
+	for _, c := range candidates {
+		if v, ok := this.routes[c]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+//BodyError is the json response to a rejected request body.
+type BodyError struct {
+	Error string `json:"error"`
+	//Output is the basic output of the validation if the body does not match the schema.
+	Output *OutputUnit `json:"output,omitempty"`
+}
+
+func writeBodyError(w http.ResponseWriter, status int, e *BodyError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
+
+type bodyKey struct{}
+
+//RequestBody returns the request body that was read and validated by a BodyValidator.
+func RequestBody(r *http.Request) ([]byte, bool) {
+	body, ok := r.Context().Value(bodyKey{}).([]byte)
+	return body, ok
+}
+
+func (this *BodyValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v := this.validator(r)
+	if v == nil {
+		this.next.ServeHTTP(w, r)
+		return
+	}
+	reader := r.Body
+	if this.MaxBodySize > 0 {
+		reader = http.MaxBytesReader(w, r.Body, this.MaxBodySize)
+	}
+	body, err := ioutil.ReadAll(reader)
+	r.Body.Close()
+	if err != nil {
+		status := http.StatusBadRequest
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeBodyError(w, status, &BodyError{Error: err.Error()})
+		return
+	}
+	if !json.Valid(body) {
+		writeBodyError(w, http.StatusBadRequest, &BodyError{Error: "request body is not valid json"})
+		return
+	}
+	out, err := v.Output(body, OutputBasic)
+	if err != nil {
+		writeBodyError(w, http.StatusInternalServerError, &BodyError{Error: err.Error()})
+		return
+	}
+	if !out.Valid {
+		writeBodyError(w, http.StatusUnprocessableEntity, &BodyError{Error: ErrInvalid.Error(), Output: out})
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), bodyKey{}, body))
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	this.next.ServeHTTP(w, r)
+}
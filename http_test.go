@@ -0,0 +1,82 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyValidator(t *testing.T) {
+	v, err := Compile([]byte(`{"properties": {"a": {"type": "integer", "maximum": 3}}, "required": ["a"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read, ok := RequestBody(r); ok && string(read) != string(body) {
+			t.Errorf("expected the already read body %s, but got %s", read, body)
+		}
+		w.Write(body)
+	})
+	h := NewBodyValidator(next)
+	h.Handle("POST", "/things", "application/json", v)
+	h.MaxBodySize = 64
+
+	tests := []struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"POST", "/things", "application/json; charset=utf-8", `{"a": 1}`, http.StatusOK},
+		{"POST", "/things", "application/json", `{"a": 4}`, http.StatusUnprocessableEntity},
+		{"POST", "/things", "application/json", `{"a": `, http.StatusBadRequest},
+		{"POST", "/things", "application/json", `{"a": 1, "b": "` + strings.Repeat("b", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{"POST", "/things", "text/plain", `{"a": 4}`, http.StatusOK},
+		{"PUT", "/things", "application/json", `{"a": 4}`, http.StatusOK},
+		{"POST", "/other", "application/json", `{"a": 4}`, http.StatusOK},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s %s %s %s: expected status %d, but got %d: %s", test.method, test.path, test.contentType, test.body, test.status, w.Code, w.Body)
+			continue
+		}
+		if test.status == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("expected the body %s to be passed on, but got %s", test.body, w.Body)
+		}
+		if test.status == http.StatusUnprocessableEntity {
+			e := &BodyError{}
+			if err := json.Unmarshal(w.Body.Bytes(), e); err != nil {
+				t.Fatal(err)
+			}
+			if e.Output == nil || e.Output.Valid || len(e.Output.Errors) == 0 {
+				t.Errorf("expected structured errors, but got %s", w.Body)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const draft4URI = "http://json-schema.org/draft-04/schema#"
+
+type inferOptions struct {
+	maxEnum              int
+	ranges               bool
+	additionalProperties bool
+}
+
+//InferOption controls how strict the schema is that Infer produces.
+type InferOption func(*inferOptions)
+
+//InferMaxEnum sets the largest number of distinct strings that are inferred as an enum.
+//Strings are only an enum if some value was seen more than once.
+//Zero turns enums off. The default is 5.
+func InferMaxEnum(max int) InferOption {
+	return func(o *inferOptions) {
+		o.maxEnum = max
+	}
+}
+
+//InferRanges sets whether the smallest and largest numbers seen are inferred as the minimum and maximum.
+//The default is true.
+func InferRanges(ranges bool) InferOption {
+	return func(o *inferOptions) {
+		o.ranges = ranges
+	}
+}
+
+//InferAdditionalProperties sets whether objects may have properties that were not seen.
+//The default is true.
+func InferAdditionalProperties(allowed bool) InferOption {
+	return func(o *inferOptions) {
+		o.additionalProperties = allowed
+	}
+}
+
+//Infer returns a draft-04 schema that all the sample json documents are valid against.
+//Properties that are present in every sample of an object are required.
+func Infer(samples [][]byte, opts ...InferOption) (*Schema, error) {
+	o := &inferOptions{maxEnum: 5, ranges: true, additionalProperties: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples to infer a schema from")
+	}
+	root := newShape()
+	for i, sample := range samples {
+		instance, err := decodeInstance(sample)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+		root.add(instance)
+	}
+	schema := root.schema(o)
+	schema.Schema = draft4URI
+	return schema, nil
+}
+
+//shape accumulates the values that were seen at one location in the samples.
+type shape struct {
+	types map[SimpleType]bool
+	//numbers counts the numbers, which are between min and max.
+	numbers int
+	min     float64
+	max     float64
+	//strings counts each distinct string.
+	strings  map[string]int
+	nstrings int
+	objects  int
+	//properties are the shapes of the members of the objects.
+	properties map[string]*shape
+	//present counts the objects that have each member.
+	present map[string]int
+	items   *shape
+}
+
+func newShape() *shape {
+	return &shape{
+		types:      make(map[SimpleType]bool),
+		strings:    make(map[string]int),
+		properties: make(map[string]*shape),
+		present:    make(map[string]int),
+	}
+}
+
+func (this *shape) add(instance interface{}) {
+	switch v := instance.(type) {
+	case nil:
+		this.types[TypeNull] = true
+	case bool:
+		this.types[TypeBoolean] = true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			this.types[TypeNumber] = true
+			return
+		}
+		if strings.ContainsAny(string(v), ".eE") {
+			this.types[TypeNumber] = true
+		} else {
+			this.types[TypeInteger] = true
+		}
+		if this.numbers == 0 || f < this.min {
+			this.min = f
+		}
+		if this.numbers == 0 || f > this.max {
+			this.max = f
+		}
+		this.numbers++
+	case string:
+		this.types[TypeString] = true
+		this.strings[v]++
+		this.nstrings++
+	case map[string]interface{}:
+		this.types[TypeObject] = true
+		this.objects++
+		for name, value := range v {
+			p, ok := this.properties[name]
+			if !ok {
+				p = newShape()
+				this.properties[name] = p
+			}
+			p.add(value)
+			this.present[name]++
+		}
+	case []interface{}:
+		this.types[TypeArray] = true
+		if this.items == nil {
+			this.items = newShape()
+		}
+		for _, item := range v {
+			this.items.add(item)
+		}
+	}
+}
+
+func (this *shape) schema(o *inferOptions) *Schema {
+	schema := &Schema{}
+	if this.types[TypeInteger] && this.types[TypeNumber] {
+		delete(this.types, TypeInteger)
+	}
+	types := Type{}
+	for _, t := range allTypes {
+		if this.types[t] {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		//only empty arrays were seen
+		return schema
+	}
+	schema.Type = &types
+	if this.numbers > 0 && o.ranges {
+		min, max := this.min, this.max
+		schema.Minimum = &min
+		schema.Maximum = &max
+	}
+	if len(this.strings) > 0 && len(types) == 1 && len(this.strings) <= o.maxEnum && this.nstrings > len(this.strings) {
+		values := make([]string, 0, len(this.strings))
+		for value := range this.strings {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			schema.Enum = append(schema.Enum, value)
+		}
+	}
+	if this.objects > 0 {
+		schema.Properties = make(map[string]*Schema, len(this.properties))
+		names := make([]string, 0, len(this.properties))
+		for name := range this.properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			schema.Properties[name] = this.properties[name].schema(o)
+			if this.present[name] == this.objects {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		if !o.additionalProperties {
+			no := false
+			schema.AdditionalProperties = &Additional{Bool: &no}
+		}
+	}
+	if this.items != nil && len(this.items.types) > 0 {
+		schema.Items = &Items{Object: this.items.schema(o)}
+	}
+	return schema
+}
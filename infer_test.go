@@ -0,0 +1,77 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"testing"
+)
+
+var inferSamples = [][]byte{
+	[]byte(`{"id": 1, "kind": "a", "price": 2.5, "name": "x", "tags": ["t"]}`),
+	[]byte(`{"id": 7, "kind": "b", "price": 3, "name": "y", "note": null}`),
+	[]byte(`{"id": 3, "kind": "a", "price": 1, "name": "z", "note": "n"}`),
+}
+
+func TestInfer(t *testing.T) {
+	schema, err := Infer(inferSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseSchema([]byte(`{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "minimum": 1, "maximum": 7},
+			"kind": {"type": "string", "enum": ["a", "b"]},
+			"name": {"type": "string"},
+			"note": {"type": ["null", "string"]},
+			"price": {"type": "number", "minimum": 1, "maximum": 3},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["id", "kind", "name", "price"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.JsonString() != want.JsonString() {
+		t.Fatalf("expected %s, but got %s", want.JsonString(), schema.JsonString())
+	}
+}
+
+func TestInferOptions(t *testing.T) {
+	schema, err := Infer(inferSamples[1:], InferMaxEnum(0), InferRanges(false), InferAdditionalProperties(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := schema.AdditionalProperties; a == nil || a.Bool == nil || *a.Bool {
+		t.Fatalf("expected no additional properties")
+	}
+	kind := schema.Properties["kind"]
+	if len(kind.Enum) != 0 || schema.Properties["id"].Minimum != nil {
+		t.Fatalf("expected no enum or ranges, but got %s", schema.JsonString())
+	}
+	v, err := CompileSchema(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range inferSamples[1:] {
+		if err := v.Validate(sample); err != nil {
+			t.Fatalf("expected %s to be valid against the inferred schema, but got %v", sample, err)
+		}
+	}
+	if err := v.Validate([]byte(`{"id": 1, "kind": "a", "price": 1, "name": "x", "other": 1}`)); err == nil {
+		t.Fatalf("expected additional properties to be invalid")
+	}
+}
@@ -0,0 +1,167 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+//instanceFrame is a level in the decoded json value that the instanceParser is walking.
+type instanceFrame struct {
+	value interface{}
+	//keys are the sorted member names of an object.
+	keys []string
+	//index is the current member or element, or 0 once the leaf of a scalar has been returned.
+	index int
+}
+
+//instanceParser is a katydid serialize.Parser over a value decoded by decodeInstance.
+type instanceParser struct {
+	frames []*instanceFrame
+}
+
+func newInstanceParser() *instanceParser {
+	return &instanceParser{}
+}
+
+func (this *instanceParser) Init(instance interface{}) {
+	this.frames = append(this.frames[:0], newInstanceFrame(instance))
+}
+
+func newInstanceFrame(instance interface{}) *instanceFrame {
+	f := &instanceFrame{value: instance, index: -1}
+	if m, ok := instance.(map[string]interface{}); ok {
+		f.keys = make([]string, 0, len(m))
+		for k := range m {
+			f.keys = append(f.keys, k)
+		}
+		sort.Strings(f.keys)
+	}
+	return f
+}
+
+func (this *instanceParser) top() *instanceFrame {
+	return this.frames[len(this.frames)-1]
+}
+
+func (this *instanceParser) Next() error {
+	f := this.top()
+	f.index++
+	switch v := f.value.(type) {
+	case map[string]interface{}:
+		if f.index >= len(f.keys) {
+			return io.EOF
+		}
+	case []interface{}:
+		if f.index >= len(v) {
+			return io.EOF
+		}
+	default:
+		if f.index > 0 {
+			return io.EOF
+		}
+	}
+	return nil
+}
+
+func (this *instanceParser) IsLeaf() bool {
+	switch this.top().value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+func (this *instanceParser) child() interface{} {
+	f := this.top()
+	switch v := f.value.(type) {
+	case map[string]interface{}:
+		return v[f.keys[f.index]]
+	case []interface{}:
+		return v[f.index]
+	}
+	panic("unreachable child of a leaf")
+}
+
+func (this *instanceParser) Down() {
+	this.frames = append(this.frames, newInstanceFrame(this.child()))
+}
+
+func (this *instanceParser) Up() {
+	this.frames = this.frames[:len(this.frames)-1]
+}
+
+func (this *instanceParser) number() (json.Number, error) {
+	f := this.top()
+	switch v := f.value.(type) {
+	case json.Number:
+		return v, nil
+	case float64:
+		return json.Number(formatFloat(v)), nil
+	case []interface{}:
+		return json.Number(strconv.Itoa(f.index)), nil
+	}
+	return "", fmt.Errorf("not a number")
+}
+
+func (this *instanceParser) Double() (float64, error) {
+	n, err := this.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Float64()
+}
+
+func (this *instanceParser) Int() (int64, error) {
+	n, err := this.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64()
+}
+
+func (this *instanceParser) Uint() (uint64, error) {
+	n, err := this.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(n), 10, 64)
+}
+
+func (this *instanceParser) Bool() (bool, error) {
+	if b, ok := this.top().value.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("not a bool")
+}
+
+func (this *instanceParser) String() (string, error) {
+	f := this.top()
+	switch v := f.value.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		return f.keys[f.index], nil
+	}
+	return "", fmt.Errorf("not a string")
+}
+
+func (this *instanceParser) Bytes() ([]byte, error) {
+	return nil, fmt.Errorf("not bytes")
+}
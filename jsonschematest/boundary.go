@@ -0,0 +1,267 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschematest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/awalterschulze/jsonschema"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Boundaries derives edge cases, per keyword, from the schema and the schemas of its properties:
+//  - numbers at, one step past and, for exclusive bounds, one step within minimum and maximum;
+//  - a multiple and a value between two multiples of multipleOf;
+//  - strings at and one past maxLength and minLength;
+//  - objects that miss each required member or have an additional member when additionalProperties is false;
+//  - every enum value and a value that is not in the enum;
+//  - a value of a type that is not allowed.
+//Each case is a valid instance, generated with the seed, with only the value of one keyword replaced.
+//Cases where the replaced value also fails or passes another keyword,
+//so that the expected validity is not the one that was derived, are left out.
+func Boundaries(description string, schema []byte, seed int64) (*Group, error) {
+	s, err := jsonschema.ParseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	base, err := jsonschema.NewGenerator(seed).Generate(s)
+	if err != nil {
+		return nil, err
+	}
+	b := &boundaries{schema: s, base: base}
+	b.walk(s, nil)
+	group := &Group{Description: description, Schema: json.RawMessage(schema)}
+	for _, c := range b.cases {
+		data, err := json.Marshal(c.instance)
+		if err != nil {
+			return nil, err
+		}
+		failures, err := jsonschema.Diagnose(s, data)
+		if err != nil {
+			return nil, err
+		}
+		if (len(failures) == 0) != c.valid {
+			continue
+		}
+		group.Tests = append(group.Tests, &Case{Description: c.description, Data: data, Valid: c.valid})
+	}
+	return group, nil
+}
+
+type boundaries struct {
+	schema *jsonschema.Schema
+	base   interface{}
+	cases  []*boundary
+}
+
+type boundary struct {
+	description string
+	instance    interface{}
+	valid       bool
+}
+
+func location(path []string) string {
+	if len(path) == 0 {
+		return "/"
+	}
+	return "/" + strings.Join(path, "/")
+}
+
+//replace adds a case where the value at the path is replaced.
+func (this *boundaries) replace(path []string, value interface{}, valid bool, format string, args ...interface{}) {
+	this.add(path, set(this.base, path, value), valid, format, args...)
+}
+
+//add adds a case that is described by the path of the value that it is about.
+func (this *boundaries) add(path []string, instance interface{}, valid bool, format string, args ...interface{}) {
+	this.cases = append(this.cases, &boundary{
+		description: location(path) + " " + fmt.Sprintf(format, args...),
+		instance:    instance,
+		valid:       valid,
+	})
+}
+
+//get returns the value at the path in the base instance.
+func (this *boundaries) get(path []string) (interface{}, bool) {
+	v := this.base
+	for _, name := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, ok = m[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+//set returns a copy of the instance with the value at the path replaced, creating objects along the path if needed.
+//The value removed{} deletes the member at the path instead.
+func set(instance interface{}, path []string, value interface{}) interface{} {
+	if len(path) == 0 {
+		return value
+	}
+	m, ok := instance.(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	c := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		c[k] = v
+	}
+	if _, ok := value.(removed); ok && len(path) == 1 {
+		delete(c, path[0])
+		return c
+	}
+	c[path[0]] = set(m[path[0]], path[1:], value)
+	return c
+}
+
+//removed is the value that set uses to delete a member.
+type removed struct{}
+
+func (this *boundaries) walk(schema *jsonschema.Schema, path []string) {
+	this.numeric(schema, path)
+	this.strings(schema, path)
+	this.object(schema, path)
+	this.enum(schema, path)
+	this.types(schema, path)
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		this.walk(schema.Properties[name], append(append([]string{}, path...), name))
+	}
+}
+
+func (this *boundaries) numeric(schema *jsonschema.Schema, path []string) {
+	step := 1.0
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		step = *schema.MultipleOf
+		this.replace(path, step*3, true, "is a multiple of %v", step)
+		this.replace(path, step*3+step/2, false, "is not a multiple of %v", step)
+	}
+	if schema.Minimum != nil {
+		min := *schema.Minimum
+		this.replace(path, min, !schema.ExclusiveMinimum, "at minimum %v", min)
+		this.replace(path, min-step, false, "one step below minimum %v", min)
+		if schema.ExclusiveMinimum {
+			this.replace(path, min+step, true, "one step above exclusive minimum %v", min)
+		}
+	}
+	if schema.Maximum != nil {
+		max := *schema.Maximum
+		this.replace(path, max, !schema.ExclusiveMaximum, "at maximum %v", max)
+		this.replace(path, max+step, false, "one step above maximum %v", max)
+		if schema.ExclusiveMaximum {
+			this.replace(path, max-step, true, "one step below exclusive maximum %v", max)
+		}
+	}
+}
+
+//stringOf returns a string with n runes, that starts with the existing string so that a pattern is more likely to still match.
+func stringOf(existing interface{}, n uint64) string {
+	s, _ := existing.(string)
+	rs := []rune(s)
+	if uint64(len(rs)) > n {
+		return string(rs[:n])
+	}
+	return s + strings.Repeat("a", int(n)-len(rs))
+}
+
+func (this *boundaries) strings(schema *jsonschema.Schema, path []string) {
+	existing, _ := this.get(path)
+	if schema.MaxLength != nil {
+		max := *schema.MaxLength
+		this.replace(path, stringOf(existing, max), true, "at maxLength %d", max)
+		this.replace(path, stringOf(existing, max+1), false, "one past maxLength %d", max)
+	}
+	if schema.MinLength > 0 {
+		min := schema.MinLength
+		this.replace(path, stringOf(existing, min), true, "at minLength %d", min)
+		this.replace(path, stringOf(existing, min-1), false, "one below minLength %d", min)
+	}
+}
+
+func (this *boundaries) object(schema *jsonschema.Schema, path []string) {
+	existing, ok := this.get(path)
+	m, isObject := existing.(map[string]interface{})
+	if !ok || !isObject {
+		return
+	}
+	for _, name := range schema.Required {
+		member := append(append([]string{}, path...), name)
+		this.add(path, set(this.base, member, removed{}), false, "without required %s", name)
+	}
+	if a := schema.AdditionalProperties; a != nil && a.Bool != nil && !*a.Bool {
+		name := "additional"
+		for i := 0; ; i++ {
+			if _, ok := m[name]; !ok {
+				if _, ok := schema.Properties[name]; !ok {
+					break
+				}
+			}
+			name = "additional" + strconv.Itoa(i)
+		}
+		member := append(append([]string{}, path...), name)
+		this.add(path, set(this.base, member, true), false, "with additional property %s", name)
+	}
+}
+
+func (this *boundaries) enum(schema *jsonschema.Schema, path []string) {
+	if len(schema.Enum) == 0 {
+		return
+	}
+	for i, v := range schema.Enum {
+		this.replace(path, v, true, "is enum value %d", i)
+	}
+	this.replace(path, "not an enum value", false, "is not an enum value")
+}
+
+//others are values of every type, except integer which is a number.
+var others = []struct {
+	typ   jsonschema.SimpleType
+	value interface{}
+}{
+	{jsonschema.TypeArray, []interface{}{}},
+	{jsonschema.TypeBoolean, true},
+	{jsonschema.TypeNull, nil},
+	{jsonschema.TypeNumber, 0.5},
+	{jsonschema.TypeObject, map[string]interface{}{}},
+	{jsonschema.TypeString, ""},
+}
+
+func (this *boundaries) types(schema *jsonschema.Schema, path []string) {
+	if schema.Type == nil {
+		return
+	}
+	allowed := make(map[jsonschema.SimpleType]bool)
+	for _, t := range *schema.Type {
+		allowed[t] = true
+	}
+	for _, other := range others {
+		if allowed[other.typ] || (other.typ == jsonschema.TypeNumber && allowed[jsonschema.TypeInteger]) {
+			continue
+		}
+		this.replace(path, other.value, false, "is not of type %s", other.typ)
+		return
+	}
+}
@@ -0,0 +1,85 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschematest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const boundarySchema = `{
+	"type": "object",
+	"properties": {
+		"count": {"type": "integer", "minimum": 10, "maximum": 20, "exclusiveMaximum": true},
+		"name": {"type": "string", "maxLength": 4, "minLength": 2},
+		"ok": {"type": "boolean"}
+	},
+	"required": ["count", "name"],
+	"additionalProperties": false
+}`
+
+func TestBoundaries(t *testing.T) {
+	g, err := Boundaries("boundaries", []byte(boundarySchema), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{
+		"/count at minimum 10":                       true,
+		"/count one step below minimum 10":           false,
+		"/count at maximum 20":                       false,
+		"/count one step above maximum 20":           false,
+		"/count one step below exclusive maximum 20": true,
+		"/count is not of type array":                false,
+		"/name at maxLength 4":                       true,
+		"/name one past maxLength 4":                 false,
+		"/name at minLength 2":                       true,
+		"/name one below minLength 2":                false,
+		"/ without required count":                   false,
+		"/ with additional property additional":      false,
+	}
+	got := make(map[string]*Case)
+	for _, c := range g.Tests {
+		got[c.Description] = c
+	}
+	for description, valid := range want {
+		c, ok := got[description]
+		if !ok {
+			t.Errorf("missing case %q", description)
+			continue
+		}
+		if c.Valid != valid {
+			t.Errorf("%q: expected valid %v, but got %v", description, valid, c.Valid)
+		}
+	}
+	data, err := json.Marshal([]*Group{g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := []*Group{}
+	if err := json.Unmarshal(data, &groups); err != nil {
+		t.Fatal(err)
+	}
+	RunFiles(t, []*File{{Filename: "boundaries.json", Groups: groups}}, nil)
+}
+
+func TestBoundariesEnum(t *testing.T) {
+	g, err := Boundaries("enum", []byte(`{"enum": [1, "a"]}`), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Tests) != 3 || !g.Tests[0].Valid || !g.Tests[1].Valid || g.Tests[2].Valid {
+		t.Fatalf("unexpected enum cases %#v", g.Tests)
+	}
+}
@@ -0,0 +1,162 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+//Package jsonschematest runs test cases, written in the format of the JSON-Schema-Test-Suite, from go tests.
+//
+//A test file is a json array of groups, where each group is a schema with the data that should or should not be valid:
+//
+//	[{
+//		"description": "small numbers",
+//		"schema": {"type": "integer", "maximum": 3},
+//		"tests": [
+//			{"description": "one is small", "data": 1, "valid": true},
+//			{"description": "four is not small", "data": 4, "valid": false}
+//		]
+//	}]
+//
+//A directory of these files is run with:
+//
+//	func TestSchemas(t *testing.T) {
+//		jsonschematest.Run(t, "testdata", nil)
+//	}
+package jsonschematest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/awalterschulze/jsonschema"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+//File is a test file.
+type File struct {
+	Filename string
+	Groups   []*Group
+}
+
+//Group is a schema and the tests for it.
+type Group struct {
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema"`
+	Tests       []*Case         `json:"tests"`
+}
+
+//Case is json data and whether it should be valid.
+type Case struct {
+	Description string          `json:"description"`
+	Data        json.RawMessage `json:"data"`
+	Valid       bool            `json:"valid"`
+}
+
+//Compiler compiles the schema of a group into the Validator that its tests are run against.
+type Compiler func(schema []byte) (*jsonschema.Validator, error)
+
+//LoadFile reads a test file.
+func LoadFile(filename string) (*File, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Filename: filename}
+	if err := json.Unmarshal(data, &f.Groups); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return f, nil
+}
+
+//LoadDir reads all the .json files in the directory and its subdirectories, sorted by filename.
+func LoadDir(dir string) ([]*File, error) {
+	filenames := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".json" {
+			filenames = append(filenames, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filenames)
+	files := make([]*File, len(filenames))
+	for i, filename := range filenames {
+		files[i], err = LoadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+//Run loads the test files in the directory and runs them with RunFiles.
+func Run(t *testing.T, dir string, compile Compiler) {
+	files, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no test files found in %s", dir)
+	}
+	RunFiles(t, files, compile)
+}
+
+//RunFiles runs every case as a subtest named by its file, group and case descriptions.
+//The schema of each group is compiled once, with jsonschema.Compile if compile is nil.
+func RunFiles(t *testing.T, files []*File, compile Compiler) {
+	if compile == nil {
+		compile = func(schema []byte) (*jsonschema.Validator, error) {
+			return jsonschema.Compile(schema)
+		}
+	}
+	for _, f := range files {
+		f := f
+		t.Run(filepath.Base(f.Filename), func(t *testing.T) {
+			for _, g := range f.Groups {
+				g := g
+				t.Run(g.Description, func(t *testing.T) {
+					runGroup(t, g, compile)
+				})
+			}
+		})
+	}
+}
+
+func runGroup(t *testing.T, g *Group, compile Compiler) {
+	v, err := compile(g.Schema)
+	if err != nil {
+		t.Fatalf("compile error %v", err)
+	}
+	for _, c := range g.Tests {
+		c := c
+		t.Run(c.Description, func(t *testing.T) {
+			err := v.Validate(c.Data)
+			if err != nil && !errors.Is(err, jsonschema.ErrInvalid) {
+				t.Fatalf("validate error %v", err)
+			}
+			if c.Valid && err != nil {
+				t.Fatalf("expected valid, but got %v", err)
+			}
+			if !c.Valid && err == nil {
+				t.Fatalf("expected invalid, but got valid")
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschematest
+
+import (
+	"github.com/awalterschulze/jsonschema"
+	"testing"
+)
+
+func TestLoadDir(t *testing.T) {
+	files, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || len(files[0].Groups) != 2 || len(files[0].Groups[1].Tests) != 3 {
+		t.Fatalf("unexpected test files %#v", files)
+	}
+}
+
+func TestRun(t *testing.T) {
+	Run(t, "testdata", nil)
+}
+
+func TestRunSuite(t *testing.T) {
+	files := []*File{}
+	for _, name := range []string{"maxLength.json", "minimum.json", "not.json"} {
+		f, err := LoadFile("../JSON-Schema-Test-Suite/tests/draft4/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	RunFiles(t, files, func(schema []byte) (*jsonschema.Validator, error) {
+		return jsonschema.Compile(schema, jsonschema.WithDraft(jsonschema.Draft4))
+	})
+}
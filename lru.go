@@ -0,0 +1,155 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+//CanonicalHash returns a hash of the json schema that does not depend on whitespace or the order of keys.
+func CanonicalHash(schema []byte) (string, error) {
+	instance, err := decodeInstance(schema)
+	if err != nil {
+		return "", err
+	}
+	//encoding/json sorts the keys of maps and keeps the text of json.Numbers.
+	canonical, err := json.Marshal(instance)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+//CompileCache is a bounded least recently used cache of Validators, in front of Compile.
+//Schemas are looked up by their CanonicalHash, together with the options.
+//A CompileCache is safe for concurrent use by multiple goroutines and
+//a schema that is compiled by one goroutine is waited for by others.
+type CompileCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[cacheKey]*list.Element
+	stats   CacheStats
+	//compile is Compile, except in tests that need it to panic.
+	compile func(schema []byte, opts ...Option) (*Validator, error)
+}
+
+//CacheStats counts the lookups of a CompileCache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	//Len is the number of Validators in the cache.
+	Len int
+}
+
+type cacheKey struct {
+	hash    string
+	draft   Draft
+	baseURI string
+}
+
+type cacheEntry struct {
+	key cacheKey
+	//done is closed once the schema is compiled.
+	done      chan struct{}
+	validator *Validator
+	err       error
+}
+
+//NewCompileCache returns a cache that holds at most size Validators.
+func NewCompileCache(size int) *CompileCache {
+	if size < 1 {
+		size = 1
+	}
+	return &CompileCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[cacheKey]*list.Element),
+		compile: Compile,
+	}
+}
+
+//Compile returns the cached Validator for the schema and options or compiles it.
+//Schemas that fail to compile are not cached.
+func (this *CompileCache) Compile(schema []byte, opts ...Option) (*Validator, error) {
+	hash, err := CanonicalHash(schema)
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	key := cacheKey{hash: hash, draft: o.draft, baseURI: o.baseURI}
+	this.mu.Lock()
+	if elem, ok := this.entries[key]; ok {
+		this.stats.Hits++
+		this.order.MoveToFront(elem)
+		this.mu.Unlock()
+		e := elem.Value.(*cacheEntry)
+		<-e.done
+		return e.validator, e.err
+	}
+	this.stats.Misses++
+	e := &cacheEntry{key: key, done: make(chan struct{})}
+	this.entries[key] = this.order.PushFront(e)
+	this.mu.Unlock()
+
+	this.compileEntry(e, schema, opts)
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if e.err != nil {
+		if elem, ok := this.entries[key]; ok && elem.Value == e {
+			this.remove(elem)
+		}
+		return nil, e.err
+	}
+	//only evict once the schema compiled, so that a failure does not evict another schema.
+	for this.order.Len() > this.size {
+		this.remove(this.order.Back())
+		this.stats.Evictions++
+	}
+	return e.validator, nil
+}
+
+//compileEntry compiles the schema into the entry and closes done, even if compiling panics,
+//so that goroutines that wait for the entry are never blocked forever.
+func (this *CompileCache) compileEntry(e *cacheEntry, schema []byte, opts []Option) {
+	defer close(e.done)
+	defer func() {
+		if r := recover(); r != nil {
+			e.validator, e.err = nil, fmt.Errorf("compile error: %v", r)
+		}
+	}()
+	e.validator, e.err = this.compile(schema, opts...)
+}
+
+func (this *CompileCache) remove(elem *list.Element) {
+	this.order.Remove(elem)
+	delete(this.entries, elem.Value.(*cacheEntry).key)
+}
+
+//Stats returns the statistics of the cache so far.
+func (this *CompileCache) Stats() CacheStats {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	stats := this.stats
+	stats.Len = this.order.Len()
+	return stats
+}
@@ -0,0 +1,155 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCanonicalHash(t *testing.T) {
+	a, err := CanonicalHash([]byte(`{"type": "object", "properties": {"a": {"maximum": 1.50}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CanonicalHash([]byte("{\"properties\":{\"a\":{\"maximum\":1.50}},\n\t\"type\":\"object\"}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("expected the same hash regardless of whitespace and key order")
+	}
+	c, err := CanonicalHash([]byte(`{"type": "object", "properties": {"a": {"maximum": 2}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == c {
+		t.Fatalf("expected a different hash for a different schema")
+	}
+	if _, err := CanonicalHash([]byte(`{`)); err == nil {
+		t.Fatalf("expected an error for invalid json")
+	}
+	if _, err := CanonicalHash([]byte(`{"type": "object"} {}`)); err == nil {
+		t.Fatalf("expected an error for trailing data")
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	cache := NewCompileCache(2)
+	a := []byte(`{"type": "string", "maxLength": 3}`)
+	b := []byte(`{"type": "integer"}`)
+	c := []byte(`{"type": "boolean"}`)
+	v1, err := cache.Compile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := cache.Compile([]byte(`{"maxLength": 3, "type": "string"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1 != v2 {
+		t.Fatalf("expected the same validator for an equivalent schema")
+	}
+	if _, err := cache.Compile(b); err != nil {
+		t.Fatal(err)
+	}
+	//a is used more recently than b, so b is evicted.
+	if _, err := cache.Compile(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Compile(c); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := cache.Compile(a); err != nil || v != v1 {
+		t.Fatalf("expected a to still be cached")
+	}
+	want := CacheStats{Hits: 3, Misses: 3, Evictions: 1, Len: 2}
+	if got := cache.Stats(); got != want {
+		t.Fatalf("expected %#v, but got %#v", want, got)
+	}
+	if _, err := cache.Compile([]byte(`{"enum": [1]}`)); err == nil {
+		t.Fatalf("expected an unsupported schema to fail")
+	}
+	if got := cache.Stats(); got.Len != 2 {
+		t.Fatalf("expected failures not to be cached, but got %#v", got)
+	}
+}
+
+func TestCompileCacheConcurrent(t *testing.T) {
+	cache := NewCompileCache(4)
+	schema := []byte(`{"type": "number", "minimum": 0}`)
+	validators := make([]*Validator, 20)
+	wg := &sync.WaitGroup{}
+	for i := range validators {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := cache.Compile(schema)
+			if err != nil {
+				t.Error(err)
+			}
+			validators[i] = v
+		}(i)
+	}
+	wg.Wait()
+	for _, v := range validators {
+		if v != validators[0] {
+			t.Fatalf("expected a single compiled validator")
+		}
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Hits != 19 {
+		t.Fatalf("expected one miss, but got %#v", stats)
+	}
+}
+
+func TestCompileCachePanic(t *testing.T) {
+	cache := NewCompileCache(4)
+	started, release := make(chan struct{}), make(chan struct{})
+	cache.compile = func(schema []byte, opts ...Option) (*Validator, error) {
+		close(started)
+		<-release
+		panic("translate")
+	}
+	schema := []byte(`{"type": "string"}`)
+	errs := make(chan error, 2)
+	go func() {
+		_, err := cache.Compile(schema)
+		errs <- err
+	}()
+	<-started
+	go func() {
+		//waits for the panicking compile
+		_, err := cache.Compile(schema)
+		errs <- err
+	}()
+	for cache.Stats().Hits == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err == nil {
+				t.Fatalf("expected the panic to be returned as an error")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected the panicking compile not to block")
+		}
+	}
+	if got := cache.Stats(); got.Len != 0 {
+		t.Fatalf("expected the panicking compile not to be cached, but got %#v", got)
+	}
+}
@@ -0,0 +1,189 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//OutputFormat is one of the standardized output formats of the json schema specification.
+//http://json-schema.org/draft/2019-09/json-schema-core.html#rfc.section.10
+type OutputFormat string
+
+const (
+	//OutputFlag only reports whether the instance is valid.
+	OutputFlag = OutputFormat("flag")
+	//OutputBasic reports a flat list of errors.
+	OutputBasic = OutputFormat("basic")
+	//OutputDetailed reports the errors nested according to the structure of the schema.
+	OutputDetailed = OutputFormat("detailed")
+	//OutputVerbose reports the result of every evaluated keyword, including the valid ones.
+	OutputVerbose = OutputFormat("verbose")
+)
+
+//ParseOutputFormat returns the OutputFormat with the given name.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(s); f {
+	case OutputFlag, OutputBasic, OutputDetailed, OutputVerbose:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q", s)
+}
+
+//OutputUnit is a node in the standardized output.
+//The children of an invalid unit are listed under Errors and those of a valid unit under Annotations.
+type OutputUnit struct {
+	Valid                   bool          `json:"valid"`
+	KeywordLocation         string        `json:"keywordLocation"`
+	AbsoluteKeywordLocation string        `json:"absoluteKeywordLocation,omitempty"`
+	InstanceLocation        string        `json:"instanceLocation"`
+	Error                   string        `json:"error,omitempty"`
+	Errors                  []*OutputUnit `json:"errors,omitempty"`
+	Annotations             []*OutputUnit `json:"annotations,omitempty"`
+
+	flag bool
+}
+
+func (this *OutputUnit) MarshalJSON() ([]byte, error) {
+	if this.flag {
+		return json.Marshal(struct {
+			Valid bool `json:"valid"`
+		}{this.Valid})
+	}
+	type unit OutputUnit
+	return json.Marshal((*unit)(this))
+}
+
+//Output validates the json data and reports the result in the requested format.
+//The translated grammar decides validity, after which the diagnostic pass is used to fill in the details.
+//Where the diagnostic pass disagrees with valid data, its failures are only reported as annotations.
+func (this *Validator) Output(data []byte, format OutputFormat) (*OutputUnit, error) {
+	if _, err := ParseOutputFormat(string(format)); err != nil {
+		return nil, err
+	}
+	valid, err := this.interpret(data)
+	if err != nil {
+		return nil, err
+	}
+	if format == OutputFlag || (valid && format != OutputVerbose) {
+		return &OutputUnit{Valid: valid, flag: format == OutputFlag}, nil
+	}
+	e, err := this.evaluate(data, valid)
+	if err != nil {
+		return nil, err
+	}
+	return newOutput(e, format, this.baseURI), nil
+}
+
+//evaluate runs the diagnostic pass and makes sure that its root agrees with the grammar.
+func (this *Validator) evaluate(data []byte, valid bool) (*evaluation, error) {
+	instance, err := decodeInstance(data)
+	if err != nil {
+		return nil, err
+	}
+	return this.evaluateInstance(instance, valid)
+}
+
+func (this *Validator) evaluateInstance(instance interface{}, valid bool) (*evaluation, error) {
+	schema, err := this.getSchema()
+	if err != nil {
+		return nil, err
+	}
+	e := evalSchema(schema, instance, "", "")
+	if valid {
+		e.valid = true
+		e.message = ""
+	} else if e.valid {
+		e.fail("does not match the translated grammar")
+	}
+	return e, nil
+}
+
+func newOutput(e *evaluation, format OutputFormat, baseURI string) *OutputUnit {
+	o := &outputter{baseURI: baseURI}
+	if i := strings.Index(baseURI, "#"); i >= 0 {
+		o.baseURI = baseURI[:i]
+	}
+	switch format {
+	case OutputBasic:
+		root := &OutputUnit{Valid: e.valid}
+		root.Errors = o.basic(e, nil)
+		return root
+	case OutputDetailed:
+		return o.detailed(e)
+	case OutputVerbose:
+		return o.verbose(e)
+	}
+	panic("unreachable output format " + string(format))
+}
+
+type outputter struct {
+	baseURI string
+}
+
+func (this *outputter) unit(e *evaluation) *OutputUnit {
+	u := &OutputUnit{
+		Valid:            e.valid,
+		KeywordLocation:  e.sch,
+		InstanceLocation: e.inst,
+		Error:            e.message,
+	}
+	if len(this.baseURI) > 0 {
+		u.AbsoluteKeywordLocation = this.baseURI + "#" + e.sch
+	}
+	return u
+}
+
+func (this *outputter) basic(e *evaluation, units []*OutputUnit) []*OutputUnit {
+	if e.valid {
+		return units
+	}
+	if len(e.message) > 0 {
+		units = append(units, this.unit(e))
+	}
+	for _, c := range e.children {
+		units = this.basic(c, units)
+	}
+	return units
+}
+
+func (this *outputter) detailed(e *evaluation) *OutputUnit {
+	u := this.unit(e)
+	for _, c := range e.children {
+		if c.valid {
+			continue
+		}
+		cu := this.detailed(c)
+		if c.structural() && len(c.message) == 0 && len(cu.Errors) == 1 {
+			cu = cu.Errors[0]
+		}
+		u.Errors = append(u.Errors, cu)
+	}
+	return u
+}
+
+func (this *outputter) verbose(e *evaluation) *OutputUnit {
+	u := this.unit(e)
+	for _, c := range e.children {
+		if e.valid {
+			u.Annotations = append(u.Annotations, this.verbose(c))
+		} else {
+			u.Errors = append(u.Errors, this.verbose(c))
+		}
+	}
+	return u
+}
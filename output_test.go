@@ -0,0 +1,121 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const outputSchema = `{
+	"type": "object",
+	"properties": {
+		"a": {"type": "integer", "maximum": 3},
+		"b": {"type": "string", "maxLength": 2}
+	}
+}`
+
+func testOutput(t *testing.T, format OutputFormat, data string) string {
+	v, err := Compile([]byte(outputSchema), WithBaseURI("http://example.com/schema.json#"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := v.Output([]byte(data), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestOutputFlag(t *testing.T) {
+	if got := testOutput(t, OutputFlag, `{"a": 4}`); got != `{"valid":false}` {
+		t.Fatalf("got %s", got)
+	}
+	if got := testOutput(t, OutputFlag, `{"a": 3}`); got != `{"valid":true}` {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestOutputBasic(t *testing.T) {
+	got := testOutput(t, OutputBasic, `{"a": 4, "b": "abc"}`)
+	want := `{"valid":false,"keywordLocation":"","instanceLocation":"","errors":[` +
+		`{"valid":false,"keywordLocation":"/properties/a/maximum","absoluteKeywordLocation":"http://example.com/schema.json#/properties/a/maximum","instanceLocation":"/a","error":"4 is greater than 3"},` +
+		`{"valid":false,"keywordLocation":"/properties/b/maxLength","absoluteKeywordLocation":"http://example.com/schema.json#/properties/b/maxLength","instanceLocation":"/b","error":"length 3 is greater than 2"}]}`
+	if got != want {
+		t.Fatalf("expected %s\n, but got %s", want, got)
+	}
+}
+
+func TestOutputDetailed(t *testing.T) {
+	v, err := Compile([]byte(outputSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := v.Output([]byte(`{"a": 4, "b": "abc"}`), OutputDetailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Valid || len(o.Errors) != 1 {
+		t.Fatalf("expected one nested error, but got %#v", o)
+	}
+	props := o.Errors[0]
+	if props.KeywordLocation != "/properties" || len(props.Errors) != 2 {
+		t.Fatalf("expected properties with two errors, but got %#v", props)
+	}
+	if props.Errors[0].KeywordLocation != "/properties/a/maximum" || props.Errors[1].KeywordLocation != "/properties/b/maxLength" {
+		t.Fatalf("unexpected errors %#v %#v", props.Errors[0], props.Errors[1])
+	}
+}
+
+func TestOutputVerbose(t *testing.T) {
+	v, err := Compile([]byte(outputSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := v.Output([]byte(`{"a": 1}`), OutputVerbose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.Valid || len(o.Annotations) != 2 {
+		t.Fatalf("expected type and properties annotations, but got %#v", o)
+	}
+	props := o.Annotations[1]
+	if len(props.Annotations) != 1 || props.Annotations[0].InstanceLocation != "/a" {
+		t.Fatalf("unexpected properties annotations %#v", props)
+	}
+}
+
+func TestOutputGrammarDecides(t *testing.T) {
+	v, err := Compile([]byte(`{"type": "object"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the diagnostic pass disagrees with the grammar, which is said to accept the array
+	e, err := v.evaluateInstance([]interface{}{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := newOutput(e, OutputVerbose, "")
+	if !o.Valid || len(o.Errors) != 0 || len(o.Annotations) != 1 {
+		t.Fatalf("expected a valid root with one annotation, but got %#v", o)
+	}
+	if a := o.Annotations[0]; a.Valid || a.KeywordLocation != "/type" {
+		t.Fatalf("expected the failed type as an annotation, but got %#v", a)
+	}
+}
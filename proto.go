@@ -0,0 +1,352 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/katydid/katydid/serialize/proto"
+	"strconv"
+	"strings"
+)
+
+//ProtoValidator validates marshaled protobuf messages of a single message type
+//against a json schema that describes the message's canonical proto3 json mapping:
+//fields are named by their json_name or lowerCamelCase name, enums are strings,
+//64 bit integers are strings and bytes are base64 encoded strings.
+//Maps and well known types are not mapped and are seen as the messages that they are encoded as.
+//A ProtoValidator is safe for concurrent use by multiple goroutines.
+type ProtoValidator struct {
+	validator *Validator
+	desc      *descriptor.FileDescriptorSet
+	pkg       string
+	message   string
+	root      *descriptor.DescriptorProto
+	messages  map[string]*descriptor.DescriptorProto
+	enums     map[string]*descriptor.EnumDescriptorProto
+}
+
+//ForProto returns a validator for marshaled protobuf messages of the type pkg.message found in desc.
+func (this *Validator) ForProto(desc *descriptor.FileDescriptorSet, pkg, message string) (*ProtoValidator, error) {
+	v := &ProtoValidator{
+		validator: this,
+		desc:      desc,
+		pkg:       pkg,
+		message:   message,
+		messages:  make(map[string]*descriptor.DescriptorProto),
+		enums:     make(map[string]*descriptor.EnumDescriptorProto),
+	}
+	for _, file := range desc.GetFile() {
+		prefix := "." + file.GetPackage()
+		if len(file.GetPackage()) == 0 {
+			prefix = ""
+		}
+		v.addMessages(prefix, file.GetMessageType())
+		v.addEnums(prefix, file.GetEnumType())
+	}
+	root, ok := v.messages["."+pkg+"."+message]
+	if !ok {
+		return nil, fmt.Errorf("message %s.%s not found", pkg, message)
+	}
+	v.root = root
+	p, err := v.newParser()
+	if err != nil {
+		return nil, err
+	}
+	m, err := this.getMachine()
+	if err != nil {
+		return nil, err
+	}
+	m.proto = p
+	this.putMachine(m)
+	return v, nil
+}
+
+func (this *ProtoValidator) addMessages(prefix string, msgs []*descriptor.DescriptorProto) {
+	for _, msg := range msgs {
+		name := prefix + "." + msg.GetName()
+		this.messages[name] = msg
+		this.addMessages(name, msg.GetNestedType())
+		this.addEnums(name, msg.GetEnumType())
+	}
+}
+
+func (this *ProtoValidator) addEnums(prefix string, enums []*descriptor.EnumDescriptorProto) {
+	for _, enum := range enums {
+		this.enums[prefix+"."+enum.GetName()] = enum
+	}
+}
+
+func (this *ProtoValidator) newParser() (*protoJsonParser, error) {
+	p, err := proto.NewProtoParser(this.pkg, this.message, this.desc)
+	if err != nil {
+		return nil, err
+	}
+	return &protoJsonParser{parser: p, types: this}, nil
+}
+
+//Validate validates a marshaled protobuf message.
+func (this *ProtoValidator) Validate(buf []byte) error {
+	m, err := this.validator.getMachine()
+	if err != nil {
+		return err
+	}
+	defer this.validator.putMachine(m)
+	if m.proto == nil || m.proto.types != this {
+		m.proto, err = this.newParser()
+		if err != nil {
+			return err
+		}
+	}
+	p := m.proto
+	if err := p.Init(buf); err != nil {
+		return err
+	}
+	valid, err := interpret(m.grammar, p)
+	if err != nil {
+		return err
+	}
+	if valid {
+		return nil
+	}
+	if err := p.Init(buf); err != nil {
+		return err
+	}
+	instance, err := parserInstance(p)
+	if err != nil {
+		return err
+	}
+	return this.validator.reportInstance(instance)
+}
+
+//protoFrame is a level in the protobuf message that the proto parser is walking.
+type protoFrame struct {
+	//msg is set when the level is the fields of a message.
+	msg *descriptor.DescriptorProto
+	//repeated is set when the level is the elements of a repeated field.
+	repeated *descriptor.FieldDescriptorProto
+	//scalar is set when the level is the value of a scalar field.
+	scalar *descriptor.FieldDescriptorProto
+	//field is the current field of a message.
+	field *descriptor.FieldDescriptorProto
+	//err is returned by Next when the level could not be mapped to a field.
+	err error
+}
+
+//protoJsonParser wraps katydid's proto parser to present the message in its proto3 json mapping.
+type protoJsonParser struct {
+	parser proto.ProtoParser
+	types  *ProtoValidator
+	frames []*protoFrame
+}
+
+func (this *protoJsonParser) Init(buf []byte) error {
+	this.frames = append(this.frames[:0], &protoFrame{msg: this.types.root})
+	return this.parser.Init(buf)
+}
+
+func (this *protoJsonParser) top() *protoFrame {
+	return this.frames[len(this.frames)-1]
+}
+
+func (this *protoJsonParser) Next() error {
+	if err := this.top().err; err != nil {
+		return err
+	}
+	if err := this.parser.Next(); err != nil {
+		return err
+	}
+	f := this.top()
+	if f.msg == nil || this.parser.IsLeaf() {
+		return nil
+	}
+	name, err := this.parser.String()
+	if err != nil {
+		return err
+	}
+	f.field = nil
+	for _, field := range f.msg.GetField() {
+		if field.GetName() == name {
+			f.field = field
+			break
+		}
+	}
+	if f.field == nil {
+		return fmt.Errorf("unknown field %s in message %s", name, f.msg.GetName())
+	}
+	return nil
+}
+
+func (this *protoJsonParser) IsLeaf() bool {
+	return this.parser.IsLeaf()
+}
+
+func (this *protoJsonParser) Down() {
+	f := this.top()
+	field := f.repeated
+	if f.msg != nil {
+		field = f.field
+	}
+	next := &protoFrame{}
+	if field == nil {
+		next.err = fmt.Errorf("cannot go down into a scalar value")
+	} else if f.msg != nil && field.IsRepeated() {
+		next.repeated = field
+	} else if field.IsMessage() {
+		msg, ok := this.types.messages[field.GetTypeName()]
+		if !ok {
+			next.err = fmt.Errorf("unknown message %s", field.GetTypeName())
+		}
+		next.msg = msg
+	} else {
+		next.scalar = field
+	}
+	this.frames = append(this.frames, next)
+	this.parser.Down()
+}
+
+func (this *protoJsonParser) Up() {
+	this.frames = this.frames[:len(this.frames)-1]
+	this.parser.Up()
+}
+
+//lowerCamelCase is how protoc derives the json_name of a field.
+func lowerCamelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) > 0 {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func jsonName(field *descriptor.FieldDescriptorProto) string {
+	if len(field.GetJsonName()) > 0 {
+		return field.GetJsonName()
+	}
+	return lowerCamelCase(field.GetName())
+}
+
+func is64Bit(field *descriptor.FieldDescriptorProto) bool {
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return true
+	}
+	return false
+}
+
+//leaf returns the field of the current leaf, if the leaf needs to be mapped to a json string.
+func (this *protoJsonParser) leaf() *descriptor.FieldDescriptorProto {
+	if !this.parser.IsLeaf() {
+		return nil
+	}
+	f := this.top().scalar
+	if f != nil && (f.IsEnum() || f.IsBytes() || is64Bit(f)) {
+		return f
+	}
+	return nil
+}
+
+func (this *protoJsonParser) String() (string, error) {
+	if !this.parser.IsLeaf() {
+		if f := this.top(); f.msg != nil {
+			return jsonName(f.field), nil
+		}
+		return this.parser.String()
+	}
+	field := this.leaf()
+	if field == nil {
+		return this.parser.String()
+	}
+	if field.IsBytes() {
+		b, err := this.parser.Bytes()
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+	if i, err := this.parser.Int(); err == nil {
+		if field.IsEnum() {
+			return this.enumName(field, int32(i))
+		}
+		return strconv.FormatInt(i, 10), nil
+	}
+	u, err := this.parser.Uint()
+	if err != nil {
+		return "", err
+	}
+	if field.IsEnum() {
+		return this.enumName(field, int32(u))
+	}
+	return strconv.FormatUint(u, 10), nil
+}
+
+func (this *protoJsonParser) enumName(field *descriptor.FieldDescriptorProto, number int32) (string, error) {
+	enum, ok := this.types.enums[field.GetTypeName()]
+	if !ok {
+		return "", fmt.Errorf("unknown enum %s", field.GetTypeName())
+	}
+	for _, v := range enum.GetValue() {
+		if v.GetNumber() == number {
+			return v.GetName(), nil
+		}
+	}
+	return "", fmt.Errorf("unknown value %d for enum %s", number, enum.GetName())
+}
+
+func (this *protoJsonParser) errIfString(typ string) error {
+	if field := this.leaf(); field != nil {
+		return fmt.Errorf("%s is mapped to a json string and not a %s", field.GetName(), typ)
+	}
+	return nil
+}
+
+func (this *protoJsonParser) Double() (float64, error) {
+	if err := this.errIfString("double"); err != nil {
+		return 0, err
+	}
+	return this.parser.Double()
+}
+
+func (this *protoJsonParser) Int() (int64, error) {
+	if err := this.errIfString("int"); err != nil {
+		return 0, err
+	}
+	return this.parser.Int()
+}
+
+func (this *protoJsonParser) Uint() (uint64, error) {
+	if err := this.errIfString("uint"); err != nil {
+		return 0, err
+	}
+	return this.parser.Uint()
+}
+
+func (this *protoJsonParser) Bool() (bool, error) {
+	return this.parser.Bool()
+}
+
+func (this *protoJsonParser) Bytes() ([]byte, error) {
+	if err := this.errIfString("bytes"); err != nil {
+		return nil, err
+	}
+	return this.parser.Bytes()
+}
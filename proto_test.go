@@ -0,0 +1,142 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/katydid/katydid/serialize/proto"
+	"testing"
+)
+
+func protoField(name string, number int32, typ descriptor.FieldDescriptorProto_Type, typeName string) *descriptor.FieldDescriptorProto {
+	label := descriptor.FieldDescriptorProto_LABEL_OPTIONAL
+	f := &descriptor.FieldDescriptorProto{
+		Name:   &name,
+		Number: &number,
+		Label:  &label,
+		Type:   &typ,
+	}
+	if len(typeName) > 0 {
+		f.TypeName = &typeName
+	}
+	return f
+}
+
+func protoString(s string) *string {
+	return &s
+}
+
+func protoInt32(i int32) *int32 {
+	return &i
+}
+
+//package test;
+//enum Color { RED = 0; GREEN = 1; }
+//message Person { string user_name = 1; int64 id = 2; Color color = 3; }
+var personDesc = &descriptor.FileDescriptorSet{
+	File: []*descriptor.FileDescriptorProto{{
+		Name:    protoString("person.proto"),
+		Package: protoString("test"),
+		EnumType: []*descriptor.EnumDescriptorProto{{
+			Name: protoString("Color"),
+			Value: []*descriptor.EnumValueDescriptorProto{
+				{Name: protoString("RED"), Number: protoInt32(0)},
+				{Name: protoString("GREEN"), Number: protoInt32(1)},
+			},
+		}},
+		MessageType: []*descriptor.DescriptorProto{{
+			Name: protoString("Person"),
+			Field: []*descriptor.FieldDescriptorProto{
+				protoField("user_name", 1, descriptor.FieldDescriptorProto_TYPE_STRING, ""),
+				protoField("id", 2, descriptor.FieldDescriptorProto_TYPE_INT64, ""),
+				protoField("color", 3, descriptor.FieldDescriptorProto_TYPE_ENUM, ".test.Color"),
+			},
+		}},
+	}},
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func marshalPerson(userName string, id int64, color int32) []byte {
+	buf := appendVarint(nil, 1<<3|2)
+	buf = appendVarint(buf, uint64(len(userName)))
+	buf = append(buf, userName...)
+	buf = appendVarint(buf, 2<<3|0)
+	buf = appendVarint(buf, uint64(id))
+	buf = appendVarint(buf, 3<<3|0)
+	return appendVarint(buf, uint64(color))
+}
+
+func TestValidateProto(t *testing.T) {
+	v, err := Compile([]byte(`{
+		"type": "object",
+		"properties": {
+			"userName": {"type": "string", "maxLength": 5},
+			"id": {"type": "string", "pattern": "^[0-9]+$"},
+			"color": {"type": "string", "pattern": "^(RED|GREEN)$"}
+		},
+		"required": ["userName", "id"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pv, err := v.ForProto(personDesc, "test", "Person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pv.Validate(marshalPerson("ann", 12345678901, 1)); err != nil {
+		t.Fatalf("expected valid, but got %v", err)
+	}
+	if err := pv.Validate(marshalPerson("annabel", 1, 1)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid, but got %v", err)
+	}
+}
+
+func TestLowerCamelCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"user_name":  "userName",
+		"id":         "id",
+		"a_b_c":      "aBC",
+		"already_ok": "alreadyOk",
+	} {
+		if got := lowerCamelCase(name); got != want {
+			t.Errorf("%s: expected %s, but got %s", name, want, got)
+		}
+	}
+}
+
+//downParser is a proto parser that can only go down.
+type downParser struct {
+	proto.ProtoParser
+}
+
+func (this downParser) Down() {}
+
+func TestProtoDownScalar(t *testing.T) {
+	name := protoField("name", 1, descriptor.FieldDescriptorProto_TYPE_STRING, "")
+	p := &protoJsonParser{parser: downParser{}, frames: []*protoFrame{{scalar: name}}}
+	p.Down()
+	if err := p.Next(); err == nil {
+		t.Fatal("expected an error when going down into a scalar value")
+	}
+}
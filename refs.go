@@ -0,0 +1,231 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//InlineRefs replaces every $ref in the schema with a copy of the schema that it refers to,
+//since the translation does not support $ref.
+//The definitions keyword is removed afterwards, because it only holds schemas to refer to.
+//
+//A reference without a file, like "#/definitions/a", refers to the schema itself.
+//A reference to another file is first looked up relative to the file that contains the reference
+//and then, using the path of the reference, in each of the dirs.
+//Absolute references, like "http://example.com/schemas/a.json", are only looked up in the dirs.
+//Recursive references cannot be inlined and return an error.
+func InlineRefs(schema []byte, filename string, dirs ...string) ([]byte, error) {
+	doc, err := decodeInstance(schema)
+	if err != nil {
+		return nil, err
+	}
+	r := &refResolver{
+		dirs:      dirs,
+		docs:      map[string]interface{}{filename: doc},
+		resolving: map[string]bool{filename + "#": true},
+	}
+	inlined, err := r.inline(doc, filename)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	if err := enc.Encode(inlined); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type refResolver struct {
+	dirs []string
+	//docs are the decoded schema files by filename.
+	docs map[string]interface{}
+	//resolving are the references that are being inlined, which are used to detect recursion.
+	resolving map[string]bool
+}
+
+//inline inlines the references in a schema, or a list of schemas, and removes its definitions.
+func (this *refResolver) inline(v interface{}, filename string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			return this.resolve(ref, filename)
+		}
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			var err error
+			switch key {
+			case "definitions":
+				continue
+			case "properties", "patternProperties", "dependencies":
+				m[key], err = this.inlineMap(value, filename)
+			case "items", "additionalItems", "additionalProperties", "allOf", "anyOf", "oneOf", "not":
+				m[key], err = this.inline(value, filename)
+			default:
+				m[key] = value
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i := range v {
+			var err error
+			a[i], err = this.inline(v[i], filename)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	}
+	return v, nil
+}
+
+//inlineMap inlines the references in the schemas of a keyword, like properties, that maps names to schemas.
+func (this *refResolver) inlineMap(v interface{}, filename string) (interface{}, error) {
+	schemas, ok := v.(map[string]interface{})
+	if !ok {
+		return v, nil
+	}
+	m := make(map[string]interface{}, len(schemas))
+	for name, schema := range schemas {
+		var err error
+		m[name], err = this.inline(schema, filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (this *refResolver) resolve(ref string, filename string) (interface{}, error) {
+	file, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		file, fragment = ref[:i], ref[i+1:]
+	}
+	if len(file) > 0 {
+		var err error
+		file, err = this.find(file, filename)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		file = filename
+	}
+	key := file + "#" + fragment
+	if this.resolving[key] {
+		return nil, fmt.Errorf("recursive $ref %s not supported", ref)
+	}
+	doc, err := this.load(file)
+	if err != nil {
+		return nil, err
+	}
+	target, err := resolvePointer(doc, fragment)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %s in %s: %v", ref, filename, err)
+	}
+	this.resolving[key] = true
+	defer delete(this.resolving, key)
+	return this.inline(target, file)
+}
+
+//find returns the name of the local file that the reference refers to.
+func (this *refResolver) find(ref string, filename string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	candidates := []string{}
+	if !u.IsAbs() && !path.IsAbs(u.Path) {
+		candidates = append(candidates, filepath.Join(filepath.Dir(filename), filepath.FromSlash(u.Path)))
+	}
+	rel := strings.TrimPrefix(u.Path, "/")
+	for _, dir := range this.dirs {
+		candidates = append(candidates, filepath.Join(dir, filepath.FromSlash(rel)))
+		candidates = append(candidates, filepath.Join(dir, path.Base(rel)))
+	}
+	for _, candidate := range candidates {
+		if _, ok := this.docs[candidate]; ok {
+			return candidate, nil
+		}
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("$ref %s in %s not found", ref, filename)
+}
+
+func (this *refResolver) load(file string) (interface{}, error) {
+	if doc, ok := this.docs[file]; ok {
+		return doc, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeInstance(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	this.docs[file] = doc
+	return doc, nil
+}
+
+//resolvePointer returns the value in the document that the json pointer refers to.
+func resolvePointer(doc interface{}, pointer string) (interface{}, error) {
+	pointer, err := url.PathUnescape(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(pointer) == 0 {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("json pointer %q does not start with /", pointer)
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.Replace(token, "~1", "/", -1)
+		token = strings.Replace(token, "~0", "~", -1)
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("json pointer %q not found", pointer)
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("json pointer %q not found", pointer)
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("json pointer %q not found", pointer)
+		}
+	}
+	return doc, nil
+}
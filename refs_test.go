@@ -0,0 +1,77 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInlineRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	shared := filepath.Join(dir, "shared")
+	if err := os.Mkdir(shared, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(shared, "name.json"), []byte(`{"type": "string", "maxLength": 3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schema := []byte(`{
+		"definitions": {"small": {"maximum": 3}},
+		"properties": {
+			"definitions": {"$ref": "#/definitions/small"},
+			"name": {"$ref": "http://example.com/schemas/name.json"}
+		}
+	}`)
+	inlined, err := InlineRefs(schema, filepath.Join(dir, "schema.json"), shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeInstance(inlined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := decodeInstance([]byte(`{
+		"properties": {
+			"definitions": {"maximum": 3},
+			"name": {"type": "string", "maxLength": 3}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalInstance(want, got) {
+		t.Fatalf("expected %s, but got %s", want, inlined)
+	}
+}
+
+func TestInlineRefsRecursive(t *testing.T) {
+	schema := []byte(`{"definitions": {"a": {"items": {"$ref": "#/definitions/a"}}}, "not": {"$ref": "#/definitions/a"}}`)
+	if _, err := InlineRefs(schema, "schema.json"); err == nil {
+		t.Fatalf("expected recursive ref error")
+	}
+	if _, err := InlineRefs([]byte(`{"not": {"$ref": "#"}}`), "schema.json"); err == nil {
+		t.Fatalf("expected recursive ref error")
+	}
+	if _, err := InlineRefs([]byte(`{"not": {"$ref": "missing.json"}}`), "schema.json"); err == nil {
+		t.Fatalf("expected missing ref error")
+	}
+}
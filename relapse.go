@@ -0,0 +1,97 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+//TranslateToRelapseString translates the schema into formatted relapse source text.
+//The same schema always results in the same text, which parses back into an equivalent grammar.
+func TranslateToRelapseString(schema *Schema) (string, error) {
+	g, err := TranslateDraft4(schema)
+	if err != nil {
+		return "", err
+	}
+	return FormatRelapse(g.String()), nil
+}
+
+const relapseIndent = "\t"
+
+//FormatRelapse formats relapse source text by placing each pattern of an interleave on its own indented line.
+//Only whitespace outside of string literals is changed.
+func FormatRelapse(src string) string {
+	buf := &bytes.Buffer{}
+	depth := 0
+	trim := func() {
+		b := buf.Bytes()
+		n := len(b)
+		for n > 0 && unicode.IsSpace(rune(b[n-1])) {
+			n--
+		}
+		buf.Truncate(n)
+	}
+	newline := func() {
+		trim()
+		buf.WriteString("\n")
+		buf.WriteString(strings.Repeat(relapseIndent, depth))
+	}
+	var quote rune
+	escaped := false
+	skipSpace := false
+	for _, c := range src {
+		if quote != 0 {
+			buf.WriteRune(c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' && quote == '"' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if skipSpace && unicode.IsSpace(c) {
+			continue
+		}
+		skipSpace = false
+		switch c {
+		case '"', '`':
+			quote = c
+			buf.WriteRune(c)
+		case '{':
+			depth++
+			buf.WriteRune(c)
+			newline()
+			skipSpace = true
+		case ';':
+			trim()
+			buf.WriteRune(c)
+			newline()
+			skipSpace = true
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+			newline()
+			buf.WriteRune(c)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return strings.TrimRightFunc(buf.String(), unicode.IsSpace) + "\n"
+}
@@ -0,0 +1,96 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"github.com/katydid/katydid/relapse/parser"
+	"testing"
+)
+
+func TestFormatRelapse(t *testing.T) {
+	src := `{a:*; "b;{}":->eq($string, "x}{;") ;c:{d:*;e:*}}`
+	want := "{\n\ta:*;\n\t\"b;{}\":->eq($string, \"x}{;\");\n\tc:{\n\t\td:*;\n\t\te:*\n\t}\n}\n"
+	got := FormatRelapse(src)
+	if got != want {
+		t.Fatalf("expected\n%s\nbut got\n%s", want, got)
+	}
+	if again := FormatRelapse(got); again != got {
+		t.Fatalf("formatting is not idempotent\n%s", again)
+	}
+}
+
+//compactRelapse removes the whitespace that is outside of string literals.
+func compactRelapse(src string) string {
+	out := []rune{}
+	var quote rune
+	escaped := false
+	for _, c := range src {
+		if quote != 0 {
+			out = append(out, c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' && quote == '"' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '"', '`':
+			quote = c
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+func TestTranslateToRelapseString(t *testing.T) {
+	tests := buildTests(t)
+	done := map[string]bool{}
+	for _, test := range tests {
+		if skippingFile[test.Filename] || done[string(test.Schema)] {
+			continue
+		}
+		done[string(test.Schema)] = true
+		schema, err := ParseSchema(test.Schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := TranslateToRelapseString(schema)
+		if err != nil {
+			t.Errorf("--- FAIL: %v: Translate error %v", test, err)
+			continue
+		}
+		again, err := TranslateToRelapseString(schema)
+		if err != nil || again != src {
+			t.Errorf("--- FAIL: %v: translation is not stable", test)
+		}
+		g, err := parser.ParseGrammar(src)
+		if err != nil {
+			t.Errorf("--- FAIL: %v: Parse error %v\n%s", test, err, src)
+			continue
+		}
+		want, err := TranslateDraft4(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compactRelapse(g.String()) != compactRelapse(want.String()) {
+			t.Errorf("--- FAIL: %v: expected %v, but got %v", test, want, g)
+		}
+	}
+}
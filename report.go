@@ -0,0 +1,556 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/katydid/katydid/serialize"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//Failure describes a single keyword that an instance failed to validate against.
+type Failure struct {
+	//Keyword is the name of the failing keyword, for example "maximum".
+	Keyword string `json:"keyword"`
+	//InstancePointer is the JSON Pointer to the failing value in the instance.
+	InstancePointer string `json:"instancePointer"`
+	//SchemaPointer is the JSON Pointer to the failing keyword in the schema.
+	SchemaPointer string `json:"schemaPointer"`
+	Message       string `json:"message"`
+	//Causes are the failures of the subschemas of an anyOf or oneOf keyword.
+	Causes []*Failure `json:"causes,omitempty"`
+}
+
+func (this *Failure) String() string {
+	return fmt.Sprintf("%s: %s (%s)", instanceLocation(this.InstancePointer), this.Message, "#"+this.SchemaPointer)
+}
+
+func instanceLocation(ptr string) string {
+	if len(ptr) == 0 {
+		return "/"
+	}
+	return ptr
+}
+
+//ValidationError is returned by Validate when the data does not match the schema.
+type ValidationError struct {
+	Failures []*Failure
+}
+
+func (this *ValidationError) Error() string {
+	ss := make([]string, len(this.Failures))
+	for i, f := range this.Failures {
+		ss[i] = f.String()
+	}
+	return ErrInvalid.Error() + ": " + strings.Join(ss, "; ")
+}
+
+//Is makes errors.Is(err, ErrInvalid) true for a ValidationError.
+func (this *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+//Diagnose walks the schema tree and reports every keyword that the json data fails to validate against.
+//It is slower than the translated grammar and is meant to explain why the grammar rejected the data.
+func Diagnose(schema *Schema, data []byte) ([]*Failure, error) {
+	e, err := evaluate(schema, data)
+	if err != nil {
+		return nil, err
+	}
+	return e.failures(), nil
+}
+
+func decodeInstance(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var instance interface{}
+	if err := dec.Decode(&instance); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the json value")
+	}
+	return instance, nil
+}
+
+//parserInstance walks a katydid parser and builds the value that decodeInstance would decode from the equivalent json.
+//Nodes that are named by a string become object members and nodes named by an index become array elements.
+func parserInstance(p serialize.Parser) (interface{}, error) {
+	var object map[string]interface{}
+	var array []interface{}
+	var leaf interface{}
+	isLeaf := false
+	for {
+		if err := p.Next(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if p.IsLeaf() {
+			leaf = leafInstance(p)
+			isLeaf = true
+			continue
+		}
+		name, nameErr := p.String()
+		p.Down()
+		child, err := parserInstance(p)
+		p.Up()
+		if err != nil {
+			return nil, err
+		}
+		if nameErr == nil {
+			if object == nil {
+				object = make(map[string]interface{})
+			}
+			object[name] = child
+		} else {
+			array = append(array, child)
+		}
+	}
+	if isLeaf {
+		return leaf, nil
+	}
+	if array != nil {
+		return array, nil
+	}
+	if object == nil {
+		object = make(map[string]interface{})
+	}
+	return object, nil
+}
+
+func leafInstance(p serialize.Parser) interface{} {
+	if s, err := p.String(); err == nil {
+		return s
+	}
+	if b, err := p.Bool(); err == nil {
+		return b
+	}
+	if i, err := p.Int(); err == nil {
+		return json.Number(strconv.FormatInt(i, 10))
+	}
+	if u, err := p.Uint(); err == nil {
+		return json.Number(strconv.FormatUint(u, 10))
+	}
+	if d, err := p.Double(); err == nil {
+		return json.Number(formatFloat(d))
+	}
+	return nil
+}
+
+func evaluate(schema *Schema, data []byte) (*evaluation, error) {
+	instance, err := decodeInstance(data)
+	if err != nil {
+		return nil, err
+	}
+	return evalSchema(schema, instance, "", ""), nil
+}
+
+func escapePointer(s string) string {
+	s = strings.Replace(s, "~", "~0", -1)
+	return strings.Replace(s, "/", "~1", -1)
+}
+
+func appendPointer(ptr string, token string) string {
+	return ptr + "/" + escapePointer(token)
+}
+
+//evaluation is a node in the tree built by the diagnostic pass.
+//A schema node has an empty keyword and keyword nodes as children.
+//Keyword nodes, like properties and anyOf, have schema nodes as children.
+type evaluation struct {
+	keyword  string
+	inst     string
+	sch      string
+	valid    bool
+	message  string
+	children []*evaluation
+}
+
+func newKeyword(keyword, inst, sch string) *evaluation {
+	return &evaluation{keyword: keyword, inst: inst, sch: appendPointer(sch, keyword), valid: true}
+}
+
+func (this *evaluation) fail(format string, args ...interface{}) *evaluation {
+	this.valid = false
+	this.message = fmt.Sprintf(format, args...)
+	return this
+}
+
+func (this *evaluation) add(children ...*evaluation) {
+	this.children = append(this.children, children...)
+}
+
+//structural keywords only fail because one of their subschemas failed.
+func (this *evaluation) structural() bool {
+	return len(this.keyword) == 0 || this.keyword == "properties" || this.keyword == "allOf"
+}
+
+func (this *evaluation) failures() []*Failure {
+	if this.valid {
+		return nil
+	}
+	causes := []*Failure{}
+	for _, c := range this.children {
+		causes = append(causes, c.failures()...)
+	}
+	if this.structural() && len(this.message) == 0 {
+		return causes
+	}
+	f := &Failure{
+		Keyword:         this.keyword,
+		InstancePointer: this.inst,
+		SchemaPointer:   this.sch,
+		Message:         this.message,
+	}
+	if len(causes) > 0 {
+		f.Causes = causes
+	}
+	return []*Failure{f}
+}
+
+func evalSchema(schema *Schema, instance interface{}, inst, sch string) *evaluation {
+	e := &evaluation{inst: inst, sch: sch, valid: true}
+	if schema.Type != nil {
+		k := newKeyword("type", inst, sch)
+		if typ := instanceType(instance); !schema.Type.matches(typ) {
+			k.fail("expected %s, but got %s", typesString(*schema.Type), typ)
+		}
+		e.add(k)
+	}
+	e.add(evalNumeric(schema.Numeric, instance, inst, sch)...)
+	e.add(evalString(schema.String, instance, inst, sch)...)
+	e.add(evalObject(schema.Object, instance, inst, sch)...)
+	e.add(evalInstance(schema.Instance, instance, inst, sch)...)
+	for _, c := range e.children {
+		if !c.valid {
+			e.valid = false
+		}
+	}
+	return e
+}
+
+func instanceType(instance interface{}) SimpleType {
+	switch v := instance.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return TypeInteger
+		}
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case float64:
+		if v == math.Trunc(v) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case string:
+		return TypeString
+	case []interface{}:
+		return TypeArray
+	case map[string]interface{}:
+		return TypeObject
+	}
+	panic(fmt.Sprintf("unknown instance type %T", instance))
+}
+
+func (this Type) matches(typ SimpleType) bool {
+	for _, t := range this {
+		if t == typ || (t == TypeNumber && typ == TypeInteger) {
+			return true
+		}
+	}
+	return false
+}
+
+func typesString(types Type) string {
+	ss := make([]string, len(types))
+	for i := range types {
+		ss[i] = string(types[i])
+	}
+	return strings.Join(ss, " or ")
+}
+
+func toFloat(instance interface{}) (float64, bool) {
+	switch v := instance.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func evalNumeric(schema Numeric, instance interface{}, inst, sch string) []*evaluation {
+	n, ok := toFloat(instance)
+	if !ok {
+		return nil
+	}
+	es := []*evaluation{}
+	if schema.MultipleOf != nil {
+		k := newKeyword("multipleOf", inst, sch)
+		v := n / *schema.MultipleOf
+		if !(v == float64(int64(v)) || v == float64(uint64(v))) {
+			k.fail("%s is not a multiple of %s", formatFloat(n), formatFloat(*schema.MultipleOf))
+		}
+		es = append(es, k)
+	}
+	if schema.Maximum != nil {
+		k := newKeyword("maximum", inst, sch)
+		max := *schema.Maximum
+		if schema.ExclusiveMaximum && n >= max {
+			k.fail("%s is not less than %s", formatFloat(n), formatFloat(max))
+		} else if n > max {
+			k.fail("%s is greater than %s", formatFloat(n), formatFloat(max))
+		}
+		es = append(es, k)
+	}
+	if schema.Minimum != nil {
+		k := newKeyword("minimum", inst, sch)
+		min := *schema.Minimum
+		if schema.ExclusiveMinimum && n <= min {
+			k.fail("%s is not greater than %s", formatFloat(n), formatFloat(min))
+		} else if n < min {
+			k.fail("%s is less than %s", formatFloat(n), formatFloat(min))
+		}
+		es = append(es, k)
+	}
+	return es
+}
+
+func evalString(schema String, instance interface{}, inst, sch string) []*evaluation {
+	s, ok := instance.(string)
+	if !ok {
+		return nil
+	}
+	es := []*evaluation{}
+	l := uint64(utf8.RuneCountInString(s))
+	if schema.MaxLength != nil {
+		k := newKeyword("maxLength", inst, sch)
+		if l > *schema.MaxLength {
+			k.fail("length %d is greater than %d", l, *schema.MaxLength)
+		}
+		es = append(es, k)
+	}
+	if schema.MinLength > 0 {
+		k := newKeyword("minLength", inst, sch)
+		if l < schema.MinLength {
+			k.fail("length %d is less than %d", l, schema.MinLength)
+		}
+		es = append(es, k)
+	}
+	if schema.Pattern != nil {
+		k := newKeyword("pattern", inst, sch)
+		r, err := regexp.Compile(*schema.Pattern)
+		if err != nil {
+			k.fail("invalid pattern %q: %v", *schema.Pattern, err)
+		} else if !r.MatchString(s) {
+			k.fail("%q does not match pattern %q", s, *schema.Pattern)
+		}
+		es = append(es, k)
+	}
+	return es
+}
+
+func evalObject(schema Object, instance interface{}, inst, sch string) []*evaluation {
+	m, ok := instance.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	es := []*evaluation{}
+	if schema.MaxProperties != nil {
+		k := newKeyword("maxProperties", inst, sch)
+		if uint64(len(m)) > *schema.MaxProperties {
+			k.fail("has %d properties, which is more than %d", len(m), *schema.MaxProperties)
+		}
+		es = append(es, k)
+	}
+	if schema.MinProperties > 0 {
+		k := newKeyword("minProperties", inst, sch)
+		if uint64(len(m)) < schema.MinProperties {
+			k.fail("has %d properties, which is less than %d", len(m), schema.MinProperties)
+		}
+		es = append(es, k)
+	}
+	if len(schema.Required) > 0 {
+		missing := []*evaluation{}
+		for _, name := range schema.Required {
+			if _, ok := m[name]; !ok {
+				missing = append(missing, newKeyword("required", inst, sch).fail("missing required property %q", name))
+			}
+		}
+		if len(missing) == 0 {
+			missing = append(missing, newKeyword("required", inst, sch))
+		}
+		es = append(es, missing...)
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(schema.Properties) > 0 {
+		k := newKeyword("properties", inst, sch)
+		for _, name := range names {
+			if prop, ok := schema.Properties[name]; ok {
+				c := evalSchema(prop, m[name], appendPointer(inst, name), appendPointer(k.sch, name))
+				if !c.valid {
+					k.valid = false
+				}
+				k.add(c)
+			}
+		}
+		es = append(es, k)
+	}
+	if schema.AdditionalProperties != nil {
+		additional := []*evaluation{}
+		for _, name := range names {
+			if _, ok := schema.Properties[name]; ok {
+				continue
+			}
+			if schema.AdditionalProperties.Bool != nil && !(*schema.AdditionalProperties.Bool) {
+				additional = append(additional, newKeyword("additionalProperties", appendPointer(inst, name), sch).fail("additional property %q is not allowed", name))
+			} else if typ := schema.AdditionalProperties.Type; typ != "" && typ != TypeUnknown && !(Type{typ}).matches(instanceType(m[name])) {
+				additional = append(additional, newKeyword("additionalProperties", appendPointer(inst, name), sch).fail("expected %s, but got %s", typ, instanceType(m[name])))
+			}
+		}
+		if len(additional) == 0 {
+			additional = append(additional, newKeyword("additionalProperties", inst, sch))
+		}
+		es = append(es, additional...)
+	}
+	return es
+}
+
+func evalAll(k *evaluation, schemas []*Schema, instance interface{}) int {
+	valid := 0
+	for i := range schemas {
+		c := evalSchema(schemas[i], instance, k.inst, appendPointer(k.sch, strconv.Itoa(i)))
+		if c.valid {
+			valid++
+		}
+		k.add(c)
+	}
+	return valid
+}
+
+func evalInstance(schema Instance, instance interface{}, inst, sch string) []*evaluation {
+	es := []*evaluation{}
+	if len(schema.Enum) > 0 {
+		k := newKeyword("enum", inst, sch)
+		found := false
+		for _, e := range schema.Enum {
+			if equalInstance(e, instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			k.fail("value is not one of the enumerated values")
+		}
+		es = append(es, k)
+	}
+	if len(schema.AllOf) > 0 {
+		k := newKeyword("allOf", inst, sch)
+		if evalAll(k, schema.AllOf, instance) != len(schema.AllOf) {
+			k.valid = false
+		}
+		es = append(es, k)
+	}
+	if len(schema.AnyOf) > 0 {
+		k := newKeyword("anyOf", inst, sch)
+		if evalAll(k, schema.AnyOf, instance) == 0 {
+			k.fail("does not match any of the %d schemas", len(schema.AnyOf))
+		}
+		es = append(es, k)
+	}
+	if len(schema.OneOf) > 0 {
+		k := newKeyword("oneOf", inst, sch)
+		if valid := evalAll(k, schema.OneOf, instance); valid == 0 {
+			k.fail("does not match any of the %d schemas", len(schema.OneOf))
+		} else if valid > 1 {
+			matches := []string{}
+			for i, c := range k.children {
+				if c.valid {
+					matches = append(matches, strconv.Itoa(i))
+				}
+			}
+			k.fail("matches more than one schema: %s", strings.Join(matches, ", "))
+		}
+		es = append(es, k)
+	}
+	if schema.Not != nil {
+		k := newKeyword("not", inst, sch)
+		c := evalSchema(schema.Not, instance, inst, k.sch)
+		if c.valid {
+			k.fail("must not match the schema")
+		}
+		k.add(c)
+		es = append(es, k)
+	}
+	return es
+}
+
+//equalInstance compares two decoded json values, where numbers are compared by value.
+func equalInstance(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch va := a.(type) {
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equalInstance(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k := range va {
+			if _, ok := vb[k]; !ok || !equalInstance(va[k], vb[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
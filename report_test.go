@@ -0,0 +1,123 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"a/b": {"type": "integer", "maximum": 3},
+			"c": {"type": "string", "maxLength": 2},
+			"d": {"anyOf": [{"type": "string"}, {"minimum": 10}]}
+		},
+		"required": ["e"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures, err := Diagnose(schema, []byte(`{"a/b": 4, "c": "abc", "d": 1, "f": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Failure{
+		{Keyword: "required", InstancePointer: "", SchemaPointer: "/required"},
+		{Keyword: "maximum", InstancePointer: "/a~1b", SchemaPointer: "/properties/a~1b/maximum"},
+		{Keyword: "maxLength", InstancePointer: "/c", SchemaPointer: "/properties/c/maxLength"},
+		{Keyword: "anyOf", InstancePointer: "/d", SchemaPointer: "/properties/d/anyOf"},
+		{Keyword: "additionalProperties", InstancePointer: "/f", SchemaPointer: "/additionalProperties"},
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("expected %d failures, but got %d: %v", len(want), len(failures), failures)
+	}
+	for i, f := range failures {
+		if f.Keyword != want[i].Keyword || f.InstancePointer != want[i].InstancePointer || f.SchemaPointer != want[i].SchemaPointer {
+			t.Errorf("expected %#v, but got %#v", want[i], f)
+		}
+	}
+	if causes := failures[3].Causes; len(causes) != 2 || causes[1].SchemaPointer != "/properties/d/anyOf/1/minimum" {
+		t.Errorf("unexpected anyOf causes %v", causes)
+	}
+}
+
+func TestDiagnoseValid(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"oneOf": [{"type": "integer"}, {"type": "string"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures, err := Diagnose(schema, []byte(`1`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, but got %v", failures)
+	}
+}
+
+func TestDiagnoseAdditionalPropertiesTrue(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"additionalProperties": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures, err := Diagnose(schema, []byte(`{"a": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, but got %v", failures)
+	}
+}
+
+func TestValidationErrorIs(t *testing.T) {
+	v, err := Compile([]byte(`{"type": "integer", "maximum": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.Validate([]byte(`4`))
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid, but got %v", err)
+	}
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected *ValidationError, but got %T", err)
+	}
+	if len(verr.Failures) != 1 || verr.Failures[0].Keyword != "maximum" {
+		t.Fatalf("unexpected failures %v", verr.Failures)
+	}
+}
+
+func TestParserInstance(t *testing.T) {
+	data := []byte(`{"b": [true, null, {"c": "d"}], "a": 1.5, "e": {}, "f": []}`)
+	want, err := decodeInstance(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newInstanceParser()
+	p.Init(want)
+	got, err := parserInstance(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//an empty array cannot be distinguished from an empty object
+	want.(map[string]interface{})["f"] = map[string]interface{}{}
+	if !equalInstance(want, got) {
+		t.Fatalf("expected %#v, but got %#v", want, got)
+	}
+}
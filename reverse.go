@@ -0,0 +1,403 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"github.com/katydid/katydid/funcs"
+	"github.com/katydid/katydid/relapse/ast"
+	"github.com/katydid/katydid/relapse/combinator"
+	"reflect"
+	"strings"
+)
+
+//Unsupported is a relapse pattern that has no json schema equivalent.
+type Unsupported struct {
+	Pattern string
+	Reason  string
+}
+
+func (this *Unsupported) String() string {
+	return this.Reason + ": " + this.Pattern
+}
+
+//UnsupportedError lists the relapse patterns that could not be translated to json schema.
+type UnsupportedError []*Unsupported
+
+func (this UnsupportedError) Error() string {
+	ss := make([]string, len(this))
+	for i := range this {
+		ss[i] = this[i].String()
+	}
+	return "no json schema equivalent for " + strings.Join(ss, "; ")
+}
+
+//TranslateRelapse is a best effort translation of a relapse grammar back into a json schema.
+//It understands the patterns that TranslateDraft4 produces.
+//Patterns without a json schema equivalent are translated to the empty schema,
+//which accepts anything, and are reported in an UnsupportedError along with the rest of the schema.
+func TranslateRelapse(g *relapse.Grammar) (*Schema, error) {
+	r := &reverser{refs: relapse.NewRefLookup(g), visiting: make(map[string]bool)}
+	main, ok := r.refs["main"]
+	if !ok {
+		return nil, fmt.Errorf("grammar has no main pattern")
+	}
+	schema := r.reverse(main)
+	if len(r.unsupported) > 0 {
+		return schema, r.unsupported
+	}
+	return schema, nil
+}
+
+type reverser struct {
+	refs        relapse.RefLookup
+	visiting    map[string]bool
+	unsupported UnsupportedError
+}
+
+func (this *reverser) unsupport(p fmt.Stringer, format string, args ...interface{}) *Schema {
+	this.unsupported = append(this.unsupported, &Unsupported{
+		Pattern: p.String(),
+		Reason:  fmt.Sprintf(format, args...),
+	})
+	return &Schema{}
+}
+
+//leafString is the expression of the leaf pattern that combinator.Value produces.
+func leafString(f funcs.Bool) string {
+	return combinator.Value(f).LeafNode.Expr.String()
+}
+
+var (
+	notNumString = leafString(funcs.Not(funcs.TypeDouble(Number())))
+	notStrString = leafString(funcs.Not(funcs.TypeString(funcs.StringVar())))
+)
+
+//typePatterns maps the patterns that translateType produces back to their types.
+//Arrays and objects are translated to the same pattern.
+var typePatterns = make(map[string]Type)
+
+func init() {
+	for _, typ := range []SimpleType{TypeArray, TypeBoolean, TypeInteger, TypeNull, TypeNumber, TypeObject, TypeString} {
+		p, err := translateType(typ)
+		if err != nil {
+			panic(err)
+		}
+		typePatterns[p.String()] = append(typePatterns[p.String()], typ)
+	}
+}
+
+func typeSchema(types ...SimpleType) *Schema {
+	t := Type(types)
+	return &Schema{Type: &t}
+}
+
+func (this *reverser) reverse(p *relapse.Pattern) *Schema {
+	if types, ok := typePatterns[p.String()]; ok {
+		return typeSchema(types...)
+	}
+	switch v := p.GetValue().(type) {
+	case *relapse.ZAny:
+		return &Schema{}
+	case *relapse.Reference:
+		if this.visiting[v.Name] {
+			return this.unsupport(p, "recursive reference")
+		}
+		ref, ok := this.refs[v.Name]
+		if !ok {
+			return this.unsupport(p, "unknown reference")
+		}
+		this.visiting[v.Name] = true
+		s := this.reverse(ref)
+		delete(this.visiting, v.Name)
+		return s
+	case *relapse.Not:
+		return &Schema{Instance: Instance{Not: this.reverse(v.Pattern)}}
+	case *relapse.And:
+		return this.reverseAnd(v)
+	case *relapse.Or:
+		return this.reverseOr(v)
+	case *relapse.LeafNode:
+		return this.reverseLeaf(p, v.Expr)
+	case *relapse.Interleave, *relapse.TreeNode, *relapse.Optional, *relapse.Empty:
+		return this.reverseObject(p)
+	case *relapse.ZeroOrMore:
+		if _, ok := this.additional(v); ok {
+			return this.reverseObject(p)
+		}
+	}
+	return this.unsupport(p, "unsupported pattern")
+}
+
+func flattenAnd(p *relapse.Pattern) []*relapse.Pattern {
+	if p.And == nil {
+		return []*relapse.Pattern{p}
+	}
+	return append(flattenAnd(p.And.LeftPattern), flattenAnd(p.And.RightPattern)...)
+}
+
+func flattenOr(p *relapse.Pattern) []*relapse.Pattern {
+	if p.Or == nil {
+		return []*relapse.Pattern{p}
+	}
+	return append(flattenOr(p.Or.LeftPattern), flattenOr(p.Or.RightPattern)...)
+}
+
+func flattenInterleave(p *relapse.Pattern) []*relapse.Pattern {
+	if p.Interleave == nil {
+		return []*relapse.Pattern{p}
+	}
+	return append(flattenInterleave(p.Interleave.LeftPattern), flattenInterleave(p.Interleave.RightPattern)...)
+}
+
+func (this *reverser) reverseAnd(v *relapse.And) *Schema {
+	ps := flattenAnd(&relapse.Pattern{And: v})
+	var merged *Schema
+	allOf := []*Schema{}
+	for _, p := range ps {
+		s := this.reverse(p)
+		if merged == nil {
+			merged = s
+		} else if m, ok := mergeSchemas(merged, s); ok {
+			merged = m
+		} else {
+			allOf = append(allOf, s)
+		}
+	}
+	if len(allOf) == 0 {
+		return merged
+	}
+	return &Schema{Instance: Instance{AllOf: append([]*Schema{merged}, allOf...)}}
+}
+
+func (this *reverser) reverseOr(v *relapse.Or) *Schema {
+	ps := flattenOr(&relapse.Pattern{Or: v})
+	schemas := make([]*Schema, len(ps))
+	onlyTypes := true
+	for i, p := range ps {
+		schemas[i] = this.reverse(p)
+		if _, ok := onlyType(schemas[i]); !ok {
+			onlyTypes = false
+		}
+	}
+	if !onlyTypes {
+		return &Schema{Instance: Instance{AnyOf: schemas}}
+	}
+	types := []SimpleType{}
+	seen := make(map[SimpleType]bool)
+	for _, s := range schemas {
+		ts, _ := onlyType(s)
+		for _, t := range ts {
+			if !seen[t] {
+				seen[t] = true
+				types = append(types, t)
+			}
+		}
+	}
+	return typeSchema(types...)
+}
+
+//onlyType returns the types of a schema that only has the type keyword.
+func onlyType(s *Schema) (Type, bool) {
+	if s.Type == nil {
+		return nil, false
+	}
+	rest := *s
+	rest.Type = nil
+	if !reflect.DeepEqual(rest, Schema{}) {
+		return nil, false
+	}
+	return *s.Type, true
+}
+
+//mergeSchemas combines two schemas into one, if they do not have any keywords in common.
+func mergeSchemas(a, b *Schema) (*Schema, bool) {
+	merged := *a
+	if !mergeFields(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(b).Elem()) {
+		return nil, false
+	}
+	return &merged, true
+}
+
+//mergeFields sets the zero fields of dst to the fields of src, descending into the embedded keyword groups.
+func mergeFields(dst, src reflect.Value) bool {
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Type().Field(i).Anonymous {
+			if !mergeFields(dst.Field(i), src.Field(i)) {
+				return false
+			}
+			continue
+		}
+		if isEmptyValue(src.Field(i)) {
+			continue
+		}
+		if !isEmptyValue(dst.Field(i)) {
+			return false
+		}
+		dst.Field(i).Set(src.Field(i))
+	}
+	return true
+}
+
+func stringName(n *relapse.NameExpr) (string, bool) {
+	if n.Name == nil || n.Name.StringValue == nil {
+		return "", false
+	}
+	return *n.Name.StringValue, true
+}
+
+//additional recognizes the additional properties that translateObject produces.
+//The returned Additional is nil when any additional property is allowed.
+func (this *reverser) additional(v *relapse.ZeroOrMore) (*Additional, bool) {
+	t := v.Pattern.TreeNode
+	if t == nil {
+		return nil, false
+	}
+	if t.Name.AnyNameExcept != nil && t.Pattern.ZAny != nil {
+		return nil, true
+	}
+	if t.Name.AnyName != nil {
+		if t.Pattern.ZAny != nil {
+			return nil, true
+		}
+		s := this.reverse(t.Pattern)
+		if types, ok := onlyType(s); ok && len(types) == 1 {
+			return &Additional{Type: types[0]}, true
+		}
+	}
+	return nil, false
+}
+
+func (this *reverser) reverseObject(p *relapse.Pattern) *Schema {
+	schema := &Schema{}
+	schema.Properties = make(map[string]*Schema)
+	no := false
+	schema.AdditionalProperties = &Additional{Bool: &no}
+	for _, elem := range flattenInterleave(p) {
+		switch v := elem.GetValue().(type) {
+		case *relapse.TreeNode:
+			name, ok := stringName(v.Name)
+			if !ok {
+				this.unsupport(elem, "only string names are supported")
+				continue
+			}
+			schema.Properties[name] = this.reverse(v.Pattern)
+			schema.Required = append(schema.Required, name)
+		case *relapse.Optional:
+			t := v.Pattern.TreeNode
+			if t == nil {
+				this.unsupport(elem, "only optional fields are supported")
+				continue
+			}
+			name, ok := stringName(t.Name)
+			if !ok {
+				this.unsupport(elem, "only string names are supported")
+				continue
+			}
+			schema.Properties[name] = this.reverse(t.Pattern)
+		case *relapse.ZeroOrMore:
+			additional, ok := this.additional(v)
+			if !ok {
+				this.unsupport(elem, "only zero or more additional properties are supported")
+				continue
+			}
+			schema.AdditionalProperties = additional
+		case *relapse.ZAny:
+			schema.AdditionalProperties = nil
+		case *relapse.Empty:
+		default:
+			this.unsupport(elem, "unsupported pattern in interleave")
+		}
+	}
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+	return schema
+}
+
+func (this *reverser) reverseLeaf(p *relapse.Pattern, expr *relapse.Expr) *Schema {
+	schema := &Schema{}
+	for _, e := range flattenFunc("and", expr) {
+		if !this.reverseConstraint(schema, e) {
+			return this.unsupport(p, "unsupported value expression %s", e)
+		}
+	}
+	return schema
+}
+
+func flattenFunc(name string, expr *relapse.Expr) []*relapse.Expr {
+	if expr.Function == nil || expr.Function.Name != name || len(expr.Function.Params) != 2 {
+		return []*relapse.Expr{expr}
+	}
+	return append(flattenFunc(name, expr.Function.Params[0]), flattenFunc(name, expr.Function.Params[1])...)
+}
+
+//reverseConstraint recognizes or(constraint, not(type(...))) as produced by translateNumeric and translateString.
+func (this *reverser) reverseConstraint(schema *Schema, expr *relapse.Expr) bool {
+	f := expr.Function
+	if f == nil || f.Name != "or" || len(f.Params) != 2 {
+		return false
+	}
+	c := f.Params[0].Function
+	if c == nil || len(c.Params) != 2 {
+		return false
+	}
+	switch f.Params[1].String() {
+	case notNumString:
+		d := c.Params[1].Terminal
+		if d == nil || d.DoubleValue == nil {
+			return false
+		}
+		v := *d.DoubleValue
+		switch c.Name {
+		case "multipleOf":
+			schema.MultipleOf = &v
+		case "le", "lt":
+			schema.Maximum = &v
+			schema.ExclusiveMaximum = c.Name == "lt"
+		case "ge", "gt":
+			schema.Minimum = &v
+			schema.ExclusiveMinimum = c.Name == "gt"
+		default:
+			return false
+		}
+		return true
+	case notStrString:
+		switch c.Name {
+		case "maxLength", "minLength":
+			i := c.Params[1].Terminal
+			if i == nil || i.IntValue == nil || *i.IntValue < 0 {
+				return false
+			}
+			n := uint64(*i.IntValue)
+			if c.Name == "maxLength" {
+				schema.MaxLength = &n
+			} else {
+				schema.MinLength = n
+			}
+		case "regex":
+			s := c.Params[0].Terminal
+			if s == nil || s.StringValue == nil {
+				return false
+			}
+			pattern := *s.StringValue
+			schema.Pattern = &pattern
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
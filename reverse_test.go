@@ -0,0 +1,76 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"github.com/katydid/katydid/relapse/ast"
+	"testing"
+)
+
+func TestTranslateRelapse(t *testing.T) {
+	schemas := []string{
+		`{}`,
+		`{"type": "string"}`,
+		`{"type": ["integer", "null"]}`,
+		`{"type": "integer", "maximum": 3, "exclusiveMinimum": true, "minimum": 1}`,
+		`{"multipleOf": 1.5}`,
+		`{"maxLength": 3, "minLength": 1, "pattern": "^a"}`,
+		`{"properties": {"a": {"type": "string"}, "b": {"type": "boolean"}}, "required": ["a"]}`,
+		`{"properties": {"a": {}}, "additionalProperties": false}`,
+		`{"additionalProperties": {"type": "number"}}`,
+		`{"anyOf": [{"type": "string"}, {"minimum": 2}]}`,
+		`{"not": {"type": "null"}}`,
+	}
+	for _, s := range schemas {
+		schema, err := ParseSchema([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := TranslateDraft4(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reversed, err := TranslateRelapse(want)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		got, err := TranslateDraft4(reversed)
+		if err != nil {
+			t.Errorf("%s: reversed into %s: %v", s, reversed.JsonString(), err)
+			continue
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s: reversed into %s, expected grammar %v, but got %v", s, reversed.JsonString(), want, got)
+		}
+	}
+}
+
+func TestTranslateRelapseUnsupported(t *testing.T) {
+	g := relapse.NewGrammar(relapse.RefLookup(map[string]*relapse.Pattern{
+		"main": relapse.NewAnd(
+			relapse.NewTreeNode(relapse.NewStringName("a"), relapse.NewZAny()),
+			relapse.NewConcat(relapse.NewZAny(), relapse.NewZAny()),
+		),
+	}))
+	schema, err := TranslateRelapse(g)
+	unsupported, ok := err.(UnsupportedError)
+	if !ok || len(unsupported) != 1 {
+		t.Fatalf("expected one unsupported pattern, but got %v", err)
+	}
+	if len(schema.Properties) != 1 || len(schema.Required) != 1 {
+		t.Fatalf("expected the supported part of the grammar, but got %s", schema.JsonString())
+	}
+}
@@ -359,6 +359,13 @@ func (this *Additional) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
+func (this Additional) MarshalJSON() ([]byte, error) {
+	if this.Bool != nil {
+		return json.Marshal(*this.Bool)
+	}
+	return json.Marshal(&aSchema{Type: &Type{this.Type}})
+}
+
 /*
    "anyOf": [
        { "$ref": "#" },
@@ -388,6 +395,13 @@ func (this *Items) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
+func (this Items) MarshalJSON() ([]byte, error) {
+	if this.Object != nil {
+		return json.Marshal(this.Object)
+	}
+	return json.Marshal(this.Array)
+}
+
 /*
    "type": "object",
    "additionalProperties": {
@@ -434,6 +448,13 @@ func (this *Dependency) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
+func (this Dependency) MarshalJSON() ([]byte, error) {
+	if this.Schema != nil {
+		return json.Marshal(this.Schema)
+	}
+	return json.Marshal(this.RequiredProperty)
+}
+
 /*
 "anyOf": [
     { "$ref": "#/definitions/simpleTypes" },
@@ -123,7 +123,7 @@ func testDebug(t *testing.T, test Test) {
 	if err != nil {
 		t.Fatalf("Translate error %v", err)
 	}
-	t.Logf("Translated = %v", g)
+	t.Logf("Translated = %v", FormatRelapse(g.String()))
 	t.Logf("Input = %v", string(test.Data))
 	if err := jsonp.Init(test.Data); err != nil {
 		t.Fatalf("parser Init error %v", err)
@@ -0,0 +1,330 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+//ValidateReader validates a single json document read from r.
+//The document is never held in memory as a whole: it is tokenized as the grammar walks it
+//and values that the grammar does not descend into are skipped over.
+//Reading stops at the first syntax error.
+//Since the document is not kept, an invalid document returns ErrInvalid without a failure report.
+func (this *Validator) ValidateReader(r io.Reader) error {
+	m, err := this.getMachine()
+	if err != nil {
+		return err
+	}
+	defer this.putMachine(m)
+	if m.stream == nil {
+		m.stream = newReaderParser()
+	}
+	m.stream.Init(r)
+	valid, err := interpret(m.grammar, m.stream)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalid
+	}
+	return m.stream.end()
+}
+
+type levelKind int
+
+const (
+	//levelUnread is a value of which no token has been read yet.
+	levelUnread = levelKind(iota)
+	levelLeaf
+	levelObject
+	levelArray
+)
+
+//level is the content of a single json value.
+type level struct {
+	kind levelKind
+	//leaf is the scalar token of a levelLeaf.
+	leaf json.Token
+	//name is the current field name of a levelObject.
+	name string
+	//index is the current element index of a levelArray.
+	index int
+	//pending is true when the current field or element's value has not been entered.
+	pending bool
+	//done is true when the closing delimiter has been read.
+	done bool
+}
+
+//readerParser is a katydid serialize.Parser that tokenizes json from an io.Reader.
+type readerParser struct {
+	dec    *json.Decoder
+	levels []*level
+}
+
+func newReaderParser() *readerParser {
+	return &readerParser{}
+}
+
+func (this *readerParser) Init(r io.Reader) {
+	this.dec = json.NewDecoder(r)
+	this.dec.UseNumber()
+	this.levels = append(this.levels[:0], &level{kind: levelUnread})
+}
+
+func (this *readerParser) top() *level {
+	return this.levels[len(this.levels)-1]
+}
+
+//end checks that nothing but whitespace follows the top level value.
+func (this *readerParser) end() error {
+	if _, err := this.dec.Token(); err != io.EOF {
+		if err == nil {
+			return fmt.Errorf("unexpected data after top-level value")
+		}
+		return err
+	}
+	return nil
+}
+
+func (this *readerParser) Next() error {
+	l := this.top()
+	switch l.kind {
+	case levelUnread:
+		tok, err := this.dec.Token()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		switch tok {
+		case json.Delim('{'):
+			l.kind = levelObject
+			return this.nextField(l)
+		case json.Delim('['):
+			l.kind = levelArray
+			l.index = -1
+			return this.nextElement(l)
+		}
+		l.kind = levelLeaf
+		l.leaf = tok
+		return nil
+	case levelLeaf:
+		return io.EOF
+	case levelObject:
+		if err := this.skipPending(l); err != nil {
+			return err
+		}
+		return this.nextField(l)
+	case levelArray:
+		if err := this.skipPending(l); err != nil {
+			return err
+		}
+		return this.nextElement(l)
+	}
+	panic("unreachable level")
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (this *readerParser) skipPending(l *level) error {
+	if !l.pending {
+		return nil
+	}
+	l.pending = false
+	return this.skipValue()
+}
+
+func (this *readerParser) nextField(l *level) error {
+	if l.done {
+		return io.EOF
+	}
+	if !this.dec.More() {
+		return this.close(l)
+	}
+	tok, err := this.dec.Token()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	name, ok := tok.(string)
+	if !ok {
+		return fmt.Errorf("expected field name, but got %v", tok)
+	}
+	l.name = name
+	l.pending = true
+	return nil
+}
+
+func (this *readerParser) nextElement(l *level) error {
+	if l.done {
+		return io.EOF
+	}
+	if !this.dec.More() {
+		return this.close(l)
+	}
+	l.index++
+	l.pending = true
+	return nil
+}
+
+//close reads the closing delimiter of an object or array.
+func (this *readerParser) close(l *level) error {
+	if _, err := this.dec.Token(); err != nil {
+		return unexpectedEOF(err)
+	}
+	l.done = true
+	return io.EOF
+}
+
+//skipValue reads over a single value without keeping it.
+func (this *readerParser) skipValue() error {
+	depth := 0
+	for {
+		tok, err := this.dec.Token()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+//skipRest reads over what is left of a level that is being exited.
+func (this *readerParser) skipRest(l *level) error {
+	switch l.kind {
+	case levelUnread:
+		return this.skipValue()
+	case levelObject, levelArray:
+		if err := this.skipPending(l); err != nil {
+			return err
+		}
+		for !l.done {
+			var err error
+			if l.kind == levelObject {
+				err = this.nextField(l)
+			} else {
+				err = this.nextElement(l)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := this.skipPending(l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (this *readerParser) IsLeaf() bool {
+	l := this.top()
+	return l.kind == levelLeaf
+}
+
+func (this *readerParser) Down() {
+	l := this.top()
+	l.pending = false
+	this.levels = append(this.levels, &level{kind: levelUnread})
+}
+
+func (this *readerParser) Up() {
+	l := this.top()
+	this.levels = this.levels[:len(this.levels)-1]
+	if err := this.skipRest(l); err != nil {
+		panic(err)
+	}
+}
+
+func (this *readerParser) number() (json.Number, error) {
+	l := this.top()
+	if l.kind == levelLeaf {
+		if n, ok := l.leaf.(json.Number); ok {
+			return n, nil
+		}
+		return "", fmt.Errorf("%v is not a number", l.leaf)
+	}
+	if l.kind == levelArray {
+		return json.Number(strconv.Itoa(l.index)), nil
+	}
+	return "", fmt.Errorf("not a number")
+}
+
+func (this *readerParser) Double() (float64, error) {
+	n, err := this.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Float64()
+}
+
+func (this *readerParser) Int() (int64, error) {
+	n, err := this.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64()
+}
+
+func (this *readerParser) Uint() (uint64, error) {
+	n, err := this.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(n), 10, 64)
+}
+
+func (this *readerParser) Bool() (bool, error) {
+	l := this.top()
+	if l.kind == levelLeaf {
+		if b, ok := l.leaf.(bool); ok {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("not a bool")
+}
+
+func (this *readerParser) String() (string, error) {
+	l := this.top()
+	if l.kind == levelLeaf {
+		if s, ok := l.leaf.(string); ok {
+			return s, nil
+		}
+		return "", fmt.Errorf("%v is not a string", l.leaf)
+	}
+	if l.kind == levelObject {
+		return l.name, nil
+	}
+	return "", fmt.Errorf("not a string")
+}
+
+func (this *readerParser) Bytes() ([]byte, error) {
+	return nil, fmt.Errorf("not bytes")
+}
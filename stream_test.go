@@ -0,0 +1,129 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/katydid/katydid/serialize"
+	"io"
+	"strings"
+	"testing"
+)
+
+//walk visits the tree in the same way as the interpreter, but skips the fields named skip.
+func walk(p serialize.Parser, skip string) (string, error) {
+	ss := []string{}
+	for {
+		if err := p.Next(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", err
+		}
+		if p.IsLeaf() {
+			if s, err := p.String(); err == nil {
+				ss = append(ss, fmt.Sprintf("%q", s))
+			} else if d, err := p.Double(); err == nil {
+				ss = append(ss, fmt.Sprintf("%v", d))
+			} else if b, err := p.Bool(); err == nil {
+				ss = append(ss, fmt.Sprintf("%v", b))
+			} else {
+				ss = append(ss, "null")
+			}
+			continue
+		}
+		name, err := p.String()
+		if err != nil {
+			i, err := p.Int()
+			if err != nil {
+				return "", err
+			}
+			name = fmt.Sprintf("%d", i)
+		}
+		if name == skip {
+			ss = append(ss, name+":_")
+			continue
+		}
+		p.Down()
+		child, err := walk(p, skip)
+		if err != nil {
+			return "", err
+		}
+		p.Up()
+		ss = append(ss, name+":"+child)
+	}
+	return "{" + strings.Join(ss, ",") + "}", nil
+}
+
+func TestReaderParser(t *testing.T) {
+	input := `{"a": 1, "b": [true, null, {"c": "d"}], "e": {"f": [1, [2]], "g": 3.5}, "h": {}}`
+	want := `{a:{1},b:{0:{true},1:{null},2:{c:{"d"}}},e:{f:{0:{1},1:{0:{2}}},g:{3.5}},h:{}}`
+	p := newReaderParser()
+	p.Init(strings.NewReader(input))
+	got, err := walk(p, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected %s, but got %s", want, got)
+	}
+	if err := p.end(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderParserSkip(t *testing.T) {
+	input := `{"a": 1, "e": {"f": [1, [2]], "g": 3.5}, "h": "i"}`
+	want := `{a:{1},e:_,h:{"i"}}`
+	p := newReaderParser()
+	p.Init(strings.NewReader(input))
+	got, err := walk(p, "e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected %s, but got %s", want, got)
+	}
+}
+
+func TestReaderParserSyntaxError(t *testing.T) {
+	p := newReaderParser()
+	p.Init(strings.NewReader(`{"a": [1, 2}`))
+	if _, err := walk(p, ""); err == nil {
+		t.Fatal("expected syntax error")
+	}
+}
+
+func TestValidateReader(t *testing.T) {
+	tests := buildTests(t)
+	for _, test := range tests {
+		if skippingFile[test.Filename] || skippingTest[test.String()] {
+			continue
+		}
+		v, err := Compile(test.Schema)
+		if err != nil {
+			t.Errorf("--- FAIL: %v: Compile error %v", test, err)
+			continue
+		}
+		err = v.ValidateReader(bytes.NewReader(test.Data))
+		if test.Valid && err != nil {
+			t.Errorf("--- FAIL: %v: expected valid got %v", test, err)
+		} else if !test.Valid && !errors.Is(err, ErrInvalid) {
+			t.Errorf("--- FAIL: %v: expected %v got %v", test, ErrInvalid, err)
+		}
+	}
+}
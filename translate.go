@@ -20,8 +20,47 @@ import (
 	"github.com/katydid/katydid/relapse/ast"
 	"github.com/katydid/katydid/relapse/combinator"
 	"sort"
+	"strconv"
 )
 
+//TranslateError is returned when a keyword of the schema cannot be translated.
+type TranslateError struct {
+	//Keyword is the keyword that is not supported.
+	Keyword string
+	//SchemaPointer is the json pointer to the keyword in the schema.
+	SchemaPointer string
+	Message       string
+}
+
+func (this *TranslateError) Error() string {
+	return fmt.Sprintf("#%s: %s", this.SchemaPointer, this.Message)
+}
+
+func notSupported(keyword string, format string, args ...interface{}) error {
+	return &TranslateError{
+		Keyword:       keyword,
+		SchemaPointer: "/" + escapePointer(keyword),
+		Message:       fmt.Sprintf(format, args...),
+	}
+}
+
+//within prefixes the location of a TranslateError with the location of the subschema it was found in.
+func within(err error, tokens ...string) error {
+	terr, ok := err.(*TranslateError)
+	if !ok {
+		return err
+	}
+	ptr := ""
+	for _, token := range tokens {
+		ptr = appendPointer(ptr, token)
+	}
+	return &TranslateError{
+		Keyword:       terr.Keyword,
+		SchemaPointer: ptr + terr.SchemaPointer,
+		Message:       terr.Message,
+	}
+}
+
 func TranslateDraft4(schema *Schema) (*relapse.Grammar, error) {
 	p, err := translate(schema)
 	if err != nil {
@@ -61,10 +100,10 @@ func translate(schema *Schema) (*relapse.Pattern, error) {
 
 func translateOne(schema *Schema) (*relapse.Pattern, error) {
 	if len(schema.Id) > 0 {
-		return nil, fmt.Errorf("id not supported")
+		return nil, notSupported("id", "id not supported")
 	}
 	if schema.Default != nil {
-		return nil, fmt.Errorf("default not supported")
+		return nil, notSupported("default", "default not supported")
 	}
 	if schema.HasNumericConstraints() {
 		p, err := translateNumeric(schema.Numeric)
@@ -72,13 +111,13 @@ func translateOne(schema *Schema) (*relapse.Pattern, error) {
 	}
 	if schema.HasStringConstraints() {
 		if schema.Type != nil && len(*schema.Type) > 1 {
-			return nil, fmt.Errorf("list of types not supported with string constraints %#v", schema)
+			return nil, notSupported("type", "list of types not supported with string constraints %#v", schema)
 		}
 		p, err := translateString(schema.String)
 		return p, err
 	}
 	if schema.HasArrayConstraints() {
-		return nil, fmt.Errorf("array not supported")
+		return nil, notSupported(arrayKeyword(schema.Array), "array not supported")
 	}
 	if schema.HasObjectConstraints() {
 		p, err := translateObject(schema)
@@ -90,21 +129,21 @@ func translateOne(schema *Schema) (*relapse.Pattern, error) {
 	}
 
 	if len(schema.Ref) > 0 {
-		return nil, fmt.Errorf("ref not supported")
+		return nil, notSupported("$ref", "ref not supported")
 	}
 	if len(schema.Format) > 0 {
-		return nil, fmt.Errorf("format not supported")
+		return nil, notSupported("format", "format not supported")
 	}
 	return relapse.NewZAny(), nil
 }
 
-func translates(schemas []*Schema) ([]*relapse.Pattern, error) {
+func translates(keyword string, schemas []*Schema) ([]*relapse.Pattern, error) {
 	ps := make([]*relapse.Pattern, len(schemas))
 	for i := range schemas {
 		var err error
 		ps[i], err = translate(schemas[i])
 		if err != nil {
-			return nil, err
+			return nil, within(err, keyword, strconv.Itoa(i))
 		}
 	}
 	return ps, nil
@@ -117,33 +156,39 @@ func rest(xs []*relapse.Pattern, index int) []*relapse.Pattern {
 }
 
 func translateInstance(schema *Schema) (*relapse.Pattern, error) {
-	if len(schema.Definitions) > 0 {
-		return nil, fmt.Errorf("definitions not supported")
+	if schema.Definitions != nil {
+		return nil, notSupported("definitions", "definitions not supported")
 	}
-	if len(schema.Enum) > 0 {
-		return nil, fmt.Errorf("enum not supported")
+	if schema.Enum != nil {
+		return nil, notSupported("enum", "enum not supported")
 	}
-	if len(schema.AllOf) > 0 {
-		ps, err := translates(schema.AllOf)
+	if schema.AllOf != nil {
+		if len(schema.AllOf) == 0 {
+			return nil, notSupported("allOf", "allOf of zero schemas not supported")
+		}
+		ps, err := translates("allOf", schema.AllOf)
 		if err != nil {
 			return nil, err
 		}
 		return relapse.NewAnd(ps...), nil
 	}
-	if len(schema.AnyOf) > 0 {
-		ps, err := translates(schema.AnyOf)
+	if schema.AnyOf != nil {
+		if len(schema.AnyOf) == 0 {
+			return nil, notSupported("anyOf", "anyOf of zero schemas not supported")
+		}
+		ps, err := translates("anyOf", schema.AnyOf)
 		if err != nil {
 			return nil, err
 		}
 		return relapse.NewOr(ps...), nil
 	}
-	if len(schema.OneOf) > 0 {
-		ps, err := translates(schema.OneOf)
+	if schema.OneOf != nil {
+		ps, err := translates("oneOf", schema.OneOf)
 		if err != nil {
 			return nil, err
 		}
 		if len(ps) == 0 {
-			return nil, fmt.Errorf("oneof of zero schemas not supported")
+			return nil, notSupported("oneOf", "oneof of zero schemas not supported")
 		}
 		if len(ps) == 1 {
 			return ps[0], nil
@@ -163,11 +208,11 @@ func translateInstance(schema *Schema) (*relapse.Pattern, error) {
 	if schema.Not != nil {
 		p, err := translate(schema.Not)
 		if err != nil {
-			return nil, err
+			return nil, within(err, "not")
 		}
 		return relapse.NewNot(p), nil
 	}
-	panic("unreachable object")
+	return nil, fmt.Errorf("no instance keyword to translate")
 }
 
 func translateType(typ SimpleType) (*relapse.Pattern, error) {
@@ -195,15 +240,15 @@ func translateType(typ SimpleType) (*relapse.Pattern, error) {
 	case TypeString:
 		return combinator.Value(funcs.TypeString(funcs.StringVar())), nil
 	}
-	panic(fmt.Sprintf("unknown simpletype: %s", typ))
+	return nil, notSupported("type", "unknown simpletype: %s", typ)
 }
 
 func translateObject(schema *Schema) (*relapse.Pattern, error) {
 	if schema.MaxProperties != nil {
-		return nil, fmt.Errorf("maxProperties not supported")
+		return nil, notSupported("maxProperties", "maxProperties not supported")
 	}
 	if schema.MinProperties > 0 {
-		return nil, fmt.Errorf("minProperties not supported")
+		return nil, notSupported("minProperties", "minProperties not supported")
 	}
 	required := make(map[string]struct{})
 	for _, req := range schema.Required {
@@ -241,8 +286,8 @@ func translateObject(schema *Schema) (*relapse.Pattern, error) {
 	if schema.AdditionalProperties != nil {
 		if schema.AdditionalProperties.Bool != nil && !(*schema.AdditionalProperties.Bool) {
 			additional = relapse.NewEmpty()
-		} else if schema.AdditionalProperties.Type != TypeUnknown {
-			typ, err := translateType(schema.AdditionalProperties.Type)
+		} else if t := schema.AdditionalProperties.Type; t != "" && t != TypeUnknown {
+			typ, err := translateType(t)
 			if err != nil {
 				return nil, err
 			}
@@ -255,23 +300,23 @@ func translateObject(schema *Schema) (*relapse.Pattern, error) {
 	for _, name := range names {
 		child, err := translate(schema.Properties[name])
 		if err != nil {
-			return nil, err
+			return nil, within(err, "properties", name)
 		}
 		patterns[name] = relapse.NewTreeNode(relapse.NewStringName(name), child)
 	}
 	for _, name := range names {
 		if _, ok := requiredIf[name]; ok {
-			return nil, fmt.Errorf("dependencies are not supported")
+			return nil, dependencyNotSupported(name)
 		}
 		if _, ok := moreProperties[name]; ok {
-			return nil, fmt.Errorf("dependencies are not supported")
+			return nil, dependencyNotSupported(name)
 		}
 		if _, ok := required[name]; !ok {
 			patterns[name] = relapse.NewOptional(patterns[name])
 		}
 	}
 	if len(schema.PatternProperties) > 0 {
-		return nil, fmt.Errorf("patternProperties not supported")
+		return nil, notSupported("patternProperties", "patternProperties not supported")
 	}
 	patternList := make([]*relapse.Pattern, 0, len(patterns))
 	for _, name := range names {
@@ -282,6 +327,14 @@ func translateObject(schema *Schema) (*relapse.Pattern, error) {
 	return relapse.NewInterleave(patternList...), nil
 }
 
+func dependencyNotSupported(name string) error {
+	return &TranslateError{
+		Keyword:       "dependencies",
+		SchemaPointer: appendPointer("/dependencies", name),
+		Message:       "dependencies are not supported",
+	}
+}
+
 func optional(p *relapse.Pattern) *relapse.Pattern {
 	return relapse.NewOr(relapse.NewEmpty(), p)
 }
@@ -346,6 +399,21 @@ func translateString(schema String) (*relapse.Pattern, error) {
 	return combinator.Value(and(list)), nil
 }
 
+//arrayKeyword returns the first array keyword that is set.
+func arrayKeyword(schema Array) string {
+	switch {
+	case schema.AdditionalItems != nil:
+		return "additionalItems"
+	case schema.Items != nil:
+		return "items"
+	case schema.MaxItems != nil:
+		return "maxItems"
+	case schema.MinItems > 0:
+		return "minItems"
+	}
+	return "uniqueItems"
+}
+
 func translateArray(schema *Schema) (*relapse.Pattern, error) {
 	if schema.Type != nil {
 		if len(*schema.Type) > 1 {
@@ -0,0 +1,335 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//TypeScript generates TypeScript declarations for the schema, with the name, and for each of its definitions.
+//
+//Objects with properties are interfaces, where properties that are not required are optional members.
+//Enums are unions of literals, oneOf and anyOf are unions and allOf is an intersection.
+//Other schemas are type aliases and a schema without any type is unknown.
+//Only references to the schema itself and its definitions are supported, see InlineRefs for other references.
+func TypeScript(schema *Schema, name string) ([]byte, error) {
+	g := &tsGen{defs: make(map[string]string)}
+	names := make(map[string]bool)
+	unique := func(s string) string {
+		name := exported(s)
+		u := name
+		for i := 2; names[u]; i++ {
+			u = name + strconv.Itoa(i)
+		}
+		names[u] = true
+		return u
+	}
+	rootName := unique(name)
+	g.defs["#"] = rootName
+	defNames := make([]string, 0, len(schema.Definitions))
+	for def := range schema.Definitions {
+		defNames = append(defNames, def)
+	}
+	sort.Strings(defNames)
+	for _, def := range defNames {
+		g.defs["#/definitions/"+def] = unique(def)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by jsonschema.TypeScript. DO NOT EDIT.\n")
+	if err := g.declare(buf, rootName, schema); err != nil {
+		return nil, err
+	}
+	for _, def := range defNames {
+		if err := g.declare(buf, g.defs["#/definitions/"+def], schema.Definitions[def]); err != nil {
+			return nil, fmt.Errorf("definitions/%s: %v", def, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+type tsGen struct {
+	//defs are the type names of the references.
+	defs map[string]string
+}
+
+func (this *tsGen) ref(ref string) (string, error) {
+	ref, err := definitionRef(ref)
+	if err != nil {
+		return "", err
+	}
+	name, ok := this.defs[ref]
+	if !ok {
+		return "", fmt.Errorf("$ref %s is not supported", ref)
+	}
+	return name, nil
+}
+
+//tsDoc writes the description, or title, of the schema as a doc comment.
+func tsDoc(buf *bytes.Buffer, indent string, schema *Schema) {
+	text := schema.Description
+	if len(text) == 0 {
+		text = schema.Title
+	}
+	if len(text) == 0 {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(buf, "%s/** %s */\n", indent, strings.Replace(lines[0], "*/", "*\\/", -1))
+		return
+	}
+	fmt.Fprintf(buf, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(buf, "%s * %s\n", indent, strings.Replace(line, "*/", "*\\/", -1))
+	}
+	fmt.Fprintf(buf, "%s */\n", indent)
+}
+
+//isInterface returns whether the schema is only an object with properties.
+func isInterface(schema *Schema) bool {
+	if len(schema.Ref) > 0 || len(schema.Properties) == 0 || len(schema.Enum) > 0 ||
+		len(schema.AllOf) > 0 || len(schema.AnyOf) > 0 || len(schema.OneOf) > 0 {
+		return false
+	}
+	return schema.Type == nil || (schema.Type.Single() && (*schema.Type)[0] == TypeObject)
+}
+
+func (this *tsGen) declare(buf *bytes.Buffer, name string, schema *Schema) error {
+	buf.WriteString("\n")
+	tsDoc(buf, "", schema)
+	if isInterface(schema) {
+		body, err := this.members(schema, "")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "export interface %s %s\n", name, body)
+		return nil
+	}
+	typ, err := this.typeOf(schema, "")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "export type %s = %s;\n", name, typ)
+	return nil
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+//members returns an object type literal with a member for each property, sorted by name.
+func (this *tsGen) members(schema *Schema, indent string) (string, error) {
+	required := make(map[string]bool)
+	for _, r := range schema.Required {
+		required[r] = true
+	}
+	props := make([]string, 0, len(schema.Properties))
+	for prop := range schema.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	buf := &bytes.Buffer{}
+	buf.WriteString("{\n")
+	for _, prop := range props {
+		p := schema.Properties[prop]
+		typ, err := this.typeOf(p, indent+"  ")
+		if err != nil {
+			return "", fmt.Errorf("properties/%s: %v", prop, err)
+		}
+		key := prop
+		if !tsIdentifier.MatchString(key) {
+			data, err := json.Marshal(key)
+			if err != nil {
+				return "", err
+			}
+			key = string(data)
+		}
+		if !required[prop] {
+			key += "?"
+		}
+		tsDoc(buf, indent+"  ", p)
+		fmt.Fprintf(buf, "%s  %s: %s;\n", indent, key, typ)
+	}
+	if len(props) == 0 {
+		if a := schema.AdditionalProperties; a == nil || a.Bool != nil && *a.Bool {
+			fmt.Fprintf(buf, "%s  [key: string]: unknown;\n", indent)
+		} else if a.Bool == nil {
+			fmt.Fprintf(buf, "%s  [key: string]: %s;\n", indent, tsSimpleType(a.Type))
+		}
+	}
+	fmt.Fprintf(buf, "%s}", indent)
+	return buf.String(), nil
+}
+
+func tsSimpleType(t SimpleType) string {
+	switch t {
+	case TypeInteger, TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeBoolean:
+		return "boolean"
+	case TypeNull:
+		return "null"
+	case TypeArray:
+		return "unknown[]"
+	case TypeObject:
+		return "{ [key: string]: unknown }"
+	}
+	return "unknown"
+}
+
+//group parenthesizes unions and intersections, so that they can be an element of an array, union or intersection.
+func group(typ string) string {
+	depth := 0
+	for i, c := range typ {
+		switch c {
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+		case '|', '&':
+			if depth == 0 && i > 0 && typ[i-1] == ' ' {
+				return "(" + typ + ")"
+			}
+		}
+	}
+	return typ
+}
+
+//literals returns a union of the enum values, if they are all literals.
+func literals(enum []interface{}) (string, bool) {
+	values := make([]string, 0, len(enum))
+	for _, v := range enum {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return "", false
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		values = append(values, string(data))
+	}
+	return strings.Join(values, " | "), true
+}
+
+func (this *tsGen) union(schemas []*Schema, indent string, sep string) (string, error) {
+	types := make([]string, 0, len(schemas))
+	for _, s := range schemas {
+		typ, err := this.typeOf(s, indent)
+		if err != nil {
+			return "", err
+		}
+		types = append(types, group(typ))
+	}
+	return strings.Join(types, sep), nil
+}
+
+//typeOf returns the TypeScript type of the schema, where nested object literals are indented by indent.
+func (this *tsGen) typeOf(schema *Schema, indent string) (string, error) {
+	if len(schema.Ref) > 0 {
+		return this.ref(schema.Ref)
+	}
+	if len(schema.Enum) > 0 {
+		if typ, ok := literals(schema.Enum); ok {
+			return typ, nil
+		}
+	}
+	var parts []string
+	var types []string
+	if schema.Type != nil {
+		for _, t := range *schema.Type {
+			typ, err := this.simpleType(schema, t, indent)
+			if err != nil {
+				return "", err
+			}
+			types = append(types, group(typ))
+		}
+	} else if len(schema.Properties) > 0 {
+		typ, err := this.members(schema, indent)
+		if err != nil {
+			return "", err
+		}
+		types = append(types, typ)
+	}
+	if len(types) > 0 {
+		parts = append(parts, strings.Join(types, " | "))
+	}
+	for _, of := range [][]*Schema{schema.AnyOf, schema.OneOf} {
+		if len(of) == 0 {
+			continue
+		}
+		typ, err := this.union(of, indent, " | ")
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, typ)
+	}
+	if len(schema.AllOf) > 0 {
+		typ, err := this.union(schema.AllOf, indent, " & ")
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, typ)
+	}
+	switch len(parts) {
+	case 0:
+		return "unknown", nil
+	case 1:
+		return parts[0], nil
+	}
+	for i := range parts {
+		parts[i] = group(parts[i])
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+func (this *tsGen) simpleType(schema *Schema, t SimpleType, indent string) (string, error) {
+	switch t {
+	case TypeObject:
+		return this.members(schema, indent)
+	case TypeArray:
+		if schema.Items == nil {
+			return "unknown[]", nil
+		}
+		if schema.Items.Object != nil {
+			typ, err := this.typeOf(schema.Items.Object, indent)
+			if err != nil {
+				return "", err
+			}
+			return group(typ) + "[]", nil
+		}
+		items := make([]string, 0, len(schema.Items.Array)+1)
+		for _, s := range schema.Items.Array {
+			typ, err := this.typeOf(s, indent)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, typ)
+		}
+		if a := schema.AdditionalItems; a == nil || a.Bool != nil && *a.Bool {
+			items = append(items, "...unknown[]")
+		} else if a.Bool == nil {
+			items = append(items, "..."+group(tsSimpleType(a.Type))+"[]")
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return tsSimpleType(t), nil
+}
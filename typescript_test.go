@@ -0,0 +1,91 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"testing"
+)
+
+func TestTypeScript(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"description": "A person.",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string", "description": "The full name."},
+			"kind": {"enum": ["admin", "user", 3]},
+			"home": {"$ref": "#/definitions/address"},
+			"tags": {"type": "array", "items": {"type": ["string", "null"]}},
+			"scores": {"type": "object", "additionalProperties": {"type": "number"}},
+			"size": {"type": "object", "properties": {"width": {"type": "number"}}, "required": ["width"]},
+			"contact": {"oneOf": [{"type": "string"}, {"$ref": "#/definitions/address"}]},
+			"pair": {"type": "array", "items": [{"type": "string"}, {"type": "boolean"}], "additionalItems": false},
+			"content-type": {"type": ["string", "null"]}
+		},
+		"required": ["id", "name", "kind"],
+		"definitions": {
+			"address": {
+				"type": "object",
+				"properties": {"street": {"type": "string"}},
+				"required": ["street"]
+			},
+			"employee": {
+				"allOf": [
+					{"$ref": "#"},
+					{"properties": {"salary": {"type": "number"}}}
+				]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := TypeScript(schema, "person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by jsonschema.TypeScript. DO NOT EDIT.
+
+/** A person. */
+export interface Person {
+  contact?: string | Address;
+  "content-type"?: string | null;
+  home?: Address;
+  id: number;
+  kind: "admin" | "user" | 3;
+  /** The full name. */
+  name: string;
+  pair?: [string, boolean];
+  scores?: {
+    [key: string]: number;
+  };
+  size?: {
+    width: number;
+  };
+  tags?: (string | null)[];
+}
+
+export interface Address {
+  street: string;
+}
+
+export type Employee = Person & {
+  salary?: number;
+};
+`
+	if string(src) != want {
+		t.Fatalf("expected\n%s\nbut got\n%s", want, src)
+	}
+}
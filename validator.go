@@ -0,0 +1,268 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"github.com/katydid/katydid/relapse/ast"
+	"github.com/katydid/katydid/relapse/interp"
+	"github.com/katydid/katydid/serialize"
+	"github.com/katydid/katydid/serialize/json"
+	"sync"
+)
+
+//ErrInvalid is the error that a *ValidationError matches with errors.Is.
+var ErrInvalid = errors.New("jsonschema: data is not valid")
+
+//Draft identifies the version of the json schema specification that a schema is translated with.
+type Draft int
+
+const (
+	Draft4 = Draft(4)
+)
+
+func (this Draft) String() string {
+	return fmt.Sprintf("draft%d", int(this))
+}
+
+//translators are the translators of the supported drafts.
+var translators = map[Draft]func(schema *Schema) (*relapse.Grammar, error){
+	Draft4: TranslateDraft4,
+}
+
+//Supported returns whether schemas can be translated with this draft.
+func (this Draft) Supported() bool {
+	_, ok := translators[this]
+	return ok
+}
+
+//Translate translates the schema into a relapse grammar using the translator for this draft.
+//A panic during the translation is returned as an error.
+func (this Draft) Translate(schema *Schema) (g *relapse.Grammar, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			g, err = nil, fmt.Errorf("translate error: %v", r)
+		}
+	}()
+	translate, ok := translators[this]
+	if !ok {
+		return nil, fmt.Errorf("%v not supported", this)
+	}
+	return translate(schema)
+}
+
+type options struct {
+	draft   Draft
+	baseURI string
+}
+
+//Option configures Compile.
+type Option func(*options)
+
+//WithDraft selects the draft that the schema is translated with.
+//The default is Draft4.
+func WithDraft(draft Draft) Option {
+	return func(o *options) {
+		o.draft = draft
+	}
+}
+
+//WithBaseURI sets the URI of the schema, which is used to report the absoluteKeywordLocation in Output.
+func WithBaseURI(uri string) Option {
+	return func(o *options) {
+		o.baseURI = uri
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{draft: Draft4}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+//Validator validates json documents against a compiled schema.
+//A Validator is safe for concurrent use by multiple goroutines.
+type Validator struct {
+	schema *Schema
+	//encodedSchema is the json of the schema if the Validator was loaded from a CompiledSchema.
+	//It is only parsed into schema when the schema is first needed.
+	encodedSchema []byte
+	schemaOnce    sync.Once
+	schemaErr     error
+	draft         Draft
+	grammar       *relapse.Grammar
+	//encoded is the marshaled grammar if the Validator was loaded from a CompiledSchema.
+	//Machines then unmarshal their own grammar instead of translating the schema again.
+	encoded []byte
+	baseURI string
+	//machines holds the per call state.
+	machines sync.Pool
+}
+
+//machine is the state that is needed to validate a single document.
+//The funcs in the grammar keep state, like multipleOf.d, that is set during Init,
+//so each machine has its own translation of the schema.
+type machine struct {
+	grammar *relapse.Grammar
+	parser  json.JsonParser
+	//stream is only created once the machine is used by ValidateReader.
+	stream *readerParser
+	//value is only created once the machine is used by ValidateValue.
+	value *tagParser
+	//xml is only created once the machine is used by ValidateXML.
+	xml *xmlParser
+	//proto is only created once the machine is used by a ProtoValidator and
+	//is replaced when the machine is used by another ProtoValidator.
+	proto *protoJsonParser
+}
+
+//Compile parses and translates the json schema into a Validator.
+func Compile(schema []byte, opts ...Option) (*Validator, error) {
+	s, err := ParseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return CompileSchema(s, opts...)
+}
+
+//CompileSchema translates an already parsed schema into a Validator.
+func CompileSchema(schema *Schema, opts ...Option) (*Validator, error) {
+	o := newOptions(opts)
+	g, err := o.draft.Translate(schema)
+	if err != nil {
+		return nil, err
+	}
+	v := &Validator{
+		schema:  schema,
+		draft:   o.draft,
+		grammar: g,
+		baseURI: o.baseURI,
+	}
+	//The first machine reuses the translated grammar, only concurrent calls translate the schema again.
+	v.putMachine(newMachine(g))
+	return v, nil
+}
+
+//Schema returns the parsed schema that this Validator was compiled from,
+//or nil if the schema of a CompiledSchema cannot be parsed.
+func (this *Validator) Schema() *Schema {
+	schema, _ := this.getSchema()
+	return schema
+}
+
+//getSchema parses the schema of a Validator that was loaded from a CompiledSchema on first use.
+func (this *Validator) getSchema() (*Schema, error) {
+	this.schemaOnce.Do(func() {
+		if this.schema == nil {
+			this.schema, this.schemaErr = ParseSchema(this.encodedSchema)
+		}
+	})
+	return this.schema, this.schemaErr
+}
+
+//Grammar returns the translated relapse grammar.
+func (this *Validator) Grammar() *relapse.Grammar {
+	return this.grammar
+}
+
+func (this *Validator) getMachine() (*machine, error) {
+	if m, ok := this.machines.Get().(*machine); ok {
+		return m, nil
+	}
+	g, err := this.newGrammar()
+	if err != nil {
+		return nil, err
+	}
+	return newMachine(g), nil
+}
+
+func newMachine(g *relapse.Grammar) *machine {
+	return &machine{grammar: g, parser: json.NewJsonParser()}
+}
+
+func (this *Validator) newGrammar() (*relapse.Grammar, error) {
+	if this.encoded == nil {
+		return this.draft.Translate(this.schema)
+	}
+	g := &relapse.Grammar{}
+	if err := g.Unmarshal(this.encoded); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (this *Validator) putMachine(m *machine) {
+	this.machines.Put(m)
+}
+
+//interpret runs the grammar over the json data.
+func (this *Validator) interpret(data []byte) (bool, error) {
+	m, err := this.getMachine()
+	if err != nil {
+		return false, err
+	}
+	defer this.putMachine(m)
+	if err := m.parser.Init(data); err != nil {
+		return false, err
+	}
+	return interpret(m.grammar, m.parser)
+}
+
+//Validate returns nil if the json data is valid, a *ValidationError if it is not
+//and any other error if the data could not be parsed or interpreted.
+//A *ValidationError satisfies errors.Is(err, ErrInvalid).
+func (this *Validator) Validate(data []byte) error {
+	valid, err := this.interpret(data)
+	if err != nil {
+		return err
+	}
+	if valid {
+		return nil
+	}
+	return this.report(data)
+}
+
+//report is only called once the grammar has rejected the data.
+//It runs the slower diagnostic pass to explain why.
+func (this *Validator) report(data []byte) error {
+	e, err := this.evaluate(data, false)
+	if err != nil {
+		return err
+	}
+	return &ValidationError{Failures: e.failures()}
+}
+
+//reportInstance is report for an instance that has already been decoded.
+func (this *Validator) reportInstance(instance interface{}) error {
+	e, err := this.evaluateInstance(instance, false)
+	if err != nil {
+		return err
+	}
+	return &ValidationError{Failures: e.failures()}
+}
+
+//interpret calls interp.Interpret and converts any panic into an error.
+func interpret(g *relapse.Grammar, p serialize.Parser) (valid bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("interpret error: %v", r)
+		}
+	}()
+	valid = interp.Interpret(g, p)
+	return
+}
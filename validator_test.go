@@ -0,0 +1,131 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestValidatorDraft4(t *testing.T) {
+	tests := buildTests(t)
+	total := 0
+	for _, test := range tests {
+		if skippingFile[test.Filename] || skippingTest[test.String()] {
+			continue
+		}
+		v, err := Compile(test.Schema)
+		if err != nil {
+			t.Errorf("--- FAIL: %v: Compile error %v", test, err)
+			continue
+		}
+		err = v.Validate(test.Data)
+		if test.Valid && err != nil {
+			t.Errorf("--- FAIL: %v: expected valid got %v", test, err)
+		} else if !test.Valid && !errors.Is(err, ErrInvalid) {
+			t.Errorf("--- FAIL: %v: expected %v got %v", test, ErrInvalid, err)
+		} else {
+			total++
+		}
+	}
+	t.Logf("number of tests passing: %d", total)
+}
+
+func TestCompileUnsupported(t *testing.T) {
+	if _, err := Compile([]byte(`{"uniqueItems": true}`)); err == nil {
+		t.Fatalf("expected translate error")
+	}
+	if _, err := Compile([]byte(`{"type": "integer"}`), WithDraft(Draft(3))); err == nil {
+		t.Fatalf("expected unsupported draft error")
+	}
+	if _, err := Compile([]byte(`{"type": `)); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	for _, schema := range []string{`{"definitions": {}}`, `{"allOf": []}`, `{"anyOf": []}`, `{"oneOf": []}`, `{"enum": []}`} {
+		_, err := Compile([]byte(schema))
+		if _, ok := err.(*TranslateError); !ok {
+			t.Errorf("%s: expected *TranslateError, but got %v", schema, err)
+		}
+	}
+}
+
+func TestAdditionalPropertiesTrue(t *testing.T) {
+	v, err := Compile([]byte(`{"properties": {"a": {"type": "integer"}}, "additionalProperties": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate([]byte(`{"a": 1, "b": "c"}`)); err != nil {
+		t.Fatalf("expected valid, but got %v", err)
+	}
+	if err := v.Validate([]byte(`{"a": "b"}`)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected %v, but got %v", ErrInvalid, err)
+	}
+}
+
+func TestTranslateErrorLocation(t *testing.T) {
+	_, err := Compile([]byte(`{"properties": {"a/b": {"anyOf": [{}, {"maxProperties": 1}]}}}`))
+	terr, ok := err.(*TranslateError)
+	if !ok {
+		t.Fatalf("expected *TranslateError, but got %v", err)
+	}
+	if terr.Keyword != "maxProperties" || terr.SchemaPointer != "/properties/a~1b/anyOf/1/maxProperties" {
+		t.Fatalf("unexpected location %v", terr)
+	}
+}
+
+func TestValidateParseError(t *testing.T) {
+	v, err := Compile([]byte(`{"type": "integer"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate([]byte(`{"a":`)); err == nil || errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+//TestValidatorConcurrent is meant to be run with go test -race
+func TestValidatorConcurrent(t *testing.T) {
+	v, err := Compile([]byte(`{"type": "number", "multipleOf": 3, "maximum": 30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 40; i++ {
+				err := v.Validate([]byte(fmt.Sprintf("%d", i)))
+				valid := i%3 == 0 && i <= 30
+				if valid && err != nil {
+					errs <- fmt.Errorf("%d: expected valid, but got %v", i, err)
+					return
+				}
+				if !valid && !errors.Is(err, ErrInvalid) {
+					errs <- fmt.Errorf("%d: expected invalid, but got %v", i, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,384 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	kreflect "github.com/katydid/katydid/serialize/reflect"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+//ValidateValue validates a Go value without marshaling it to json.
+//The grammar is run over katydid's reflect parser, where struct fields are named,
+//omitted and flattened from embedded structs, as encoding/json would name, omit and flatten them.
+//Only if the value is not valid, is it marshaled to json to report the failures.
+func (this *Validator) ValidateValue(v interface{}) error {
+	m, err := this.getMachine()
+	if err != nil {
+		return err
+	}
+	defer this.putMachine(m)
+	if m.value == nil {
+		m.value = newTagParser()
+	}
+	m.value.Init(reflect.ValueOf(v))
+	valid, err := interpret(m.grammar, m.value)
+	if err != nil {
+		return err
+	}
+	if valid {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return this.report(data)
+}
+
+//field is the json view of a struct field.
+type field struct {
+	name      string
+	omitEmpty bool
+	omit      bool
+}
+
+func jsonField(f reflect.StructField) field {
+	if len(f.PkgPath) > 0 && !f.Anonymous {
+		return field{omit: true}
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return field{omit: true}
+	}
+	parts := strings.Split(tag, ",")
+	jf := field{name: f.Name}
+	if len(parts[0]) > 0 {
+		jf.name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			jf.omitEmpty = true
+		}
+	}
+	return jf
+}
+
+//tagName returns the name in the json tag of the struct field, if it has one.
+func tagName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
+//embeddedStruct returns the struct type of an embedded field that encoding/json flattens.
+func embeddedStruct(f reflect.StructField) (reflect.Type, bool) {
+	if !f.Anonymous || len(tagName(f)) > 0 {
+		return nil, false
+	}
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
+
+//fieldIndexes caches the result of jsonFields per struct type.
+var fieldIndexes sync.Map
+
+//jsonFields returns the index of the struct field that encoding/json uses for each json name,
+//where the fields of embedded structs are flattened.
+//A shallower field hides deeper fields with the same name and of fields at the same depth
+//only a single tagged field is used, otherwise none of them are.
+func jsonFields(t reflect.Type) map[string][]int {
+	if fields, ok := fieldIndexes.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+	type candidate struct {
+		index  []int
+		tagged bool
+	}
+	candidates := make(map[string][]candidate)
+	var walk func(t reflect.Type, index []int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, visited map[reflect.Type]bool) {
+		visited[t] = true
+		defer delete(visited, t)
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fieldIndex := append(append([]int{}, index...), i)
+			if et, ok := embeddedStruct(sf); ok {
+				if !visited[et] {
+					walk(et, fieldIndex, visited)
+				}
+				continue
+			}
+			if sf.Anonymous && len(sf.PkgPath) > 0 {
+				continue
+			}
+			jf := jsonField(sf)
+			if jf.omit {
+				continue
+			}
+			candidates[jf.name] = append(candidates[jf.name], candidate{fieldIndex, len(tagName(sf)) > 0})
+		}
+	}
+	walk(t, nil, make(map[reflect.Type]bool))
+	fields := make(map[string][]int, len(candidates))
+	for name, cs := range candidates {
+		depth := len(cs[0].index)
+		for _, c := range cs {
+			if len(c.index) < depth {
+				depth = len(c.index)
+			}
+		}
+		var dominant, tagged []candidate
+		for _, c := range cs {
+			if len(c.index) != depth {
+				continue
+			}
+			dominant = append(dominant, c)
+			if c.tagged {
+				tagged = append(tagged, c)
+			}
+		}
+		if len(dominant) == 1 {
+			fields[name] = dominant[0].index
+		} else if len(tagged) == 1 {
+			fields[name] = tagged[0].index
+		}
+	}
+	fieldIndexes.Store(t, fields)
+	return fields
+}
+
+func equalIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+//frame follows the value that the reflect parser is currently walking.
+type frame struct {
+	value reflect.Value
+	//embedded are the embedded structs, innermost last, whose fields the reflect parser is walking.
+	//Their fields are presented as fields of value.
+	embedded []reflect.Value
+	//index is the index of the innermost embedded struct in value.
+	index []int
+	//fields are the jsonFields of a struct value.
+	fields map[string][]int
+	//name is the json name of the current struct field.
+	name string
+	//child is the value of the current field or element.
+	child reflect.Value
+}
+
+func newFrame(v reflect.Value) *frame {
+	f := &frame{value: v}
+	if v.Kind() == reflect.Struct {
+		f.fields = jsonFields(v.Type())
+	}
+	return f
+}
+
+//current returns the struct whose fields the reflect parser is walking.
+func (this *frame) current() reflect.Value {
+	if len(this.embedded) > 0 {
+		return this.embedded[len(this.embedded)-1]
+	}
+	return this.value
+}
+
+//tagParser wraps katydid's reflect parser and renames struct fields to their json names.
+type tagParser struct {
+	parser kreflect.ReflectParser
+	frames []*frame
+}
+
+func newTagParser() *tagParser {
+	return &tagParser{parser: kreflect.NewReflectParser()}
+}
+
+func (this *tagParser) Init(v reflect.Value) {
+	v = indirect(v)
+	this.parser.Init(v)
+	this.frames = append(this.frames[:0], newFrame(v))
+}
+
+func (this *tagParser) top() *frame {
+	return this.frames[len(this.frames)-1]
+}
+
+func (this *tagParser) Next() error {
+	f := this.top()
+	for {
+		if err := this.parser.Next(); err != nil {
+			if err == io.EOF && len(f.embedded) > 0 {
+				//continue with the fields after the embedded struct
+				this.parser.Up()
+				f.embedded = f.embedded[:len(f.embedded)-1]
+				f.index = f.index[:len(f.index)-1]
+				continue
+			}
+			return err
+		}
+		if this.parser.IsLeaf() {
+			return nil
+		}
+		switch f.value.Kind() {
+		case reflect.Struct:
+			current := f.current()
+			goName, err := this.parser.String()
+			if err != nil {
+				return err
+			}
+			sf, ok := current.Type().FieldByName(goName)
+			if !ok {
+				return fmt.Errorf("unknown field %s in %v", goName, current.Type())
+			}
+			child := current.FieldByIndex(sf.Index)
+			index := append(append([]int{}, f.index...), sf.Index...)
+			if _, ok := embeddedStruct(sf); ok {
+				if embedded := indirect(child); embedded.IsValid() && embedded.Kind() == reflect.Struct {
+					f.embedded = append(f.embedded, embedded)
+					f.index = index
+					this.parser.Down()
+				}
+				continue
+			}
+			jf := jsonField(sf)
+			if !equalIndex(f.fields[jf.name], index) || (jf.omitEmpty && isEmptyValue(child)) {
+				continue
+			}
+			f.name = jf.name
+			f.child = indirect(child)
+		case reflect.Slice, reflect.Array:
+			i, err := this.parser.Int()
+			if err != nil {
+				return err
+			}
+			f.child = indirect(f.value.Index(int(i)))
+		case reflect.Map:
+			key, err := this.mapKey(f.value.Type().Key())
+			if err != nil {
+				return err
+			}
+			f.child = indirect(f.value.MapIndex(key))
+		default:
+			f.child = reflect.Value{}
+		}
+		return nil
+	}
+}
+
+//mapKey returns the key of the current map entry.
+func (this *tagParser) mapKey(t reflect.Type) (reflect.Value, error) {
+	switch t.Kind() {
+	case reflect.String:
+		s, err := this.parser.String()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(s).Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := this.parser.Int()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(i).Convert(t), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := this.parser.Uint()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(u).Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("map key of type %v not supported", t)
+}
+
+func (this *tagParser) IsLeaf() bool {
+	return this.parser.IsLeaf()
+}
+
+func (this *tagParser) Down() {
+	f := this.top()
+	this.frames = append(this.frames, newFrame(f.child))
+	this.parser.Down()
+}
+
+func (this *tagParser) Up() {
+	this.frames = this.frames[:len(this.frames)-1]
+	this.parser.Up()
+}
+
+func (this *tagParser) String() (string, error) {
+	if !this.parser.IsLeaf() && this.top().value.Kind() == reflect.Struct {
+		return this.top().name, nil
+	}
+	return this.parser.String()
+}
+
+func (this *tagParser) Double() (float64, error) {
+	return this.parser.Double()
+}
+
+func (this *tagParser) Int() (int64, error) {
+	return this.parser.Int()
+}
+
+func (this *tagParser) Uint() (uint64, error) {
+	return this.parser.Uint()
+}
+
+func (this *tagParser) Bool() (bool, error) {
+	return this.parser.Bool()
+}
+
+func (this *tagParser) Bytes() ([]byte, error) {
+	return this.parser.Bytes()
+}
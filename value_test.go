@@ -0,0 +1,171 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type valueAddress struct {
+	Street string `json:"street"`
+	Number int64  `json:"number"`
+}
+
+type valuePerson struct {
+	Name     string        `json:"name"`
+	Age      int64         `json:"age,omitempty"`
+	Address  *valueAddress `json:"address,omitempty"`
+	Password string        `json:"-"`
+	internal string
+}
+
+const valueSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "maxLength": 5},
+		"age": {"type": "integer", "minimum": 0},
+		"address": {
+			"type": "object",
+			"properties": {
+				"street": {"type": "string"},
+				"number": {"type": "integer", "minimum": 1}
+			},
+			"required": ["street"]
+		}
+	},
+	"required": ["name", "age"],
+	"additionalProperties": false
+}`
+
+func TestValidateValue(t *testing.T) {
+	v, err := Compile([]byte(valueSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := &valuePerson{Name: "Ann", Age: 3, Address: &valueAddress{Street: "Main", Number: 1}, Password: "secret"}
+	if err := v.ValidateValue(valid); err != nil {
+		t.Fatalf("expected valid, but got %v", err)
+	}
+	invalids := []*valuePerson{
+		{Name: "Annabel", Age: 3},
+		{Name: "Ann"},
+		{Name: "Ann", Age: 3, Address: &valueAddress{Street: "Main"}},
+	}
+	for _, invalid := range invalids {
+		if err := v.ValidateValue(invalid); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected invalid for %#v, but got %v", invalid, err)
+		}
+	}
+}
+
+func TestJsonField(t *testing.T) {
+	typ := reflect.TypeOf(valuePerson{})
+	want := []field{
+		{name: "name"},
+		{name: "age", omitEmpty: true},
+		{name: "address", omitEmpty: true},
+		{omit: true},
+		{omit: true},
+	}
+	for i := range want {
+		if got := jsonField(typ.Field(i)); got != want[i] {
+			t.Errorf("field %d: expected %#v, but got %#v", i, want[i], got)
+		}
+	}
+}
+
+type valueBase struct {
+	ID   string `json:"id"`
+	Name string `json:"base_name"`
+}
+
+type valueEmbedding struct {
+	*valueBase
+	Name  string                  `json:"name"`
+	Homes map[string]valueAddress `json:"homes"`
+	Other interface{}             `json:"other"`
+}
+
+const valueNestedSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"base_name": {"type": "string"},
+		"name": {"type": "string"},
+		"homes": {
+			"type": "object",
+			"additionalProperties": {
+				"type": "object",
+				"properties": {
+					"street": {"type": "string"},
+					"number": {"type": "integer", "minimum": 1}
+				},
+				"required": ["street", "number"],
+				"additionalProperties": false
+			}
+		},
+		"other": {
+			"type": "object",
+			"properties": {
+				"street": {"type": "string"}
+			},
+			"required": ["street"]
+		}
+	},
+	"required": ["id", "name"],
+	"additionalProperties": false
+}`
+
+func TestValidateValueNested(t *testing.T) {
+	v, err := Compile([]byte(valueNestedSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := &valueEmbedding{
+		valueBase: &valueBase{ID: "1", Name: "base"},
+		Name:      "Ann",
+		Homes:     map[string]valueAddress{"home": {Street: "Main", Number: 1}},
+		Other:     &valueAddress{Street: "Side", Number: 2},
+	}
+	if err := v.ValidateValue(valid); err != nil {
+		t.Fatalf("expected valid, but got %v", err)
+	}
+	invalids := []*valueEmbedding{
+		{Name: "Ann", Homes: map[string]valueAddress{}, Other: valueAddress{Street: "Side"}},
+		{valueBase: &valueBase{ID: "1"}, Name: "Ann", Homes: map[string]valueAddress{"home": {Street: "Main"}}, Other: valueAddress{Street: "Side"}},
+		{valueBase: &valueBase{ID: "1"}, Name: "Ann", Homes: map[string]valueAddress{}, Other: map[string]string{"road": "Side"}},
+	}
+	for _, invalid := range invalids {
+		if err := v.ValidateValue(invalid); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected invalid for %#v, but got %v", invalid, err)
+		}
+	}
+}
+
+func TestJsonFields(t *testing.T) {
+	got := jsonFields(reflect.TypeOf(valueEmbedding{}))
+	want := map[string][]int{
+		"id":        {0, 0},
+		"base_name": {0, 1},
+		"name":      {1},
+		"homes":     {2},
+		"other":     {3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, but got %v", want, got)
+	}
+}
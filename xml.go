@@ -0,0 +1,220 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//XMLMapping defines how an xml document is mapped to the json that the schema describes:
+//  - the root element is the top level value and its name is ignored;
+//  - an element without attributes or child elements is the string of its text;
+//  - any other element is an object, where child elements are properties and
+//    attributes are properties named with AttributePrefix;
+//  - elements that are repeated under the same parent are arrays;
+//  - the text of an element that is an object is the property named TextKey, if it is not only whitespace.
+//Namespaces are ignored and only the local names are used.
+type XMLMapping struct {
+	//AttributePrefix is prepended to attribute names. The default is "@".
+	AttributePrefix string
+	//TextKey names the text of an element that is an object. The default is "#text".
+	TextKey string
+	//InferTypes maps text that is a json number, true, false or null to that json value instead of a string.
+	InferTypes bool
+	//Arrays are the names of elements that are arrays, even when they are not repeated.
+	Arrays []string
+}
+
+//DefaultXMLMapping is the mapping that is used when ValidateXML is given a nil mapping.
+//Its AttributePrefix and TextKey are also used when they are empty in another mapping.
+var DefaultXMLMapping = &XMLMapping{
+	AttributePrefix: "@",
+	TextKey:         "#text",
+}
+
+//ValidateXML interprets the grammar over katydid's xml parser, which presents the xml document as the json that it maps to according to the mapping.
+//A nil mapping is the DefaultXMLMapping.
+//The document is only decoded into memory to report why it is not valid.
+func (this *Validator) ValidateXML(data []byte, mapping *XMLMapping) error {
+	mapping = mapping.withDefaults()
+	m, err := this.getMachine()
+	if err != nil {
+		return err
+	}
+	defer this.putMachine(m)
+	if m.xml == nil {
+		m.xml = newXMLParser()
+	}
+	if err := m.xml.Init(data, mapping); err != nil {
+		return err
+	}
+	valid, err := interpret(m.grammar, m.xml)
+	if err != nil {
+		return err
+	}
+	if valid {
+		return nil
+	}
+	instance, err := mapping.Decode(data)
+	if err != nil {
+		return err
+	}
+	return this.reportInstance(instance)
+}
+
+//withDefaults returns the mapping with the AttributePrefix and TextKey of the DefaultXMLMapping where they are empty.
+func (this *XMLMapping) withDefaults() *XMLMapping {
+	if this == nil {
+		return DefaultXMLMapping
+	}
+	if len(this.AttributePrefix) > 0 && len(this.TextKey) > 0 {
+		return this
+	}
+	m := *this
+	if len(m.AttributePrefix) == 0 {
+		m.AttributePrefix = DefaultXMLMapping.AttributePrefix
+	}
+	if len(m.TextKey) == 0 {
+		m.TextKey = DefaultXMLMapping.TextKey
+	}
+	return &m
+}
+
+//xmlElement is an element that is being decoded.
+type xmlElement struct {
+	attrs    []xml.Attr
+	names    []string
+	children map[string][]interface{}
+	text     bytes.Buffer
+}
+
+//Decode maps the xml document to the value that decoding the equivalent json would produce.
+func (this *XMLMapping) Decode(data []byte) (interface{}, error) {
+	return this.withDefaults().decode(data)
+}
+
+func (this *XMLMapping) decode(data []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	stack := []*xmlElement{}
+	var root interface{}
+	hasRoot := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, &xmlElement{attrs: t.Attr, children: make(map[string][]interface{})})
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			v := this.value(e)
+			if len(stack) == 0 {
+				root = v
+				hasRoot = true
+				continue
+			}
+			parent := stack[len(stack)-1]
+			name := t.Name.Local
+			if _, ok := parent.children[name]; !ok {
+				parent.names = append(parent.names, name)
+			}
+			parent.children[name] = append(parent.children[name], v)
+		}
+	}
+	if !hasRoot {
+		return nil, fmt.Errorf("xml document has no root element")
+	}
+	return root, nil
+}
+
+func (this *XMLMapping) isArray(name string) bool {
+	for _, a := range this.Arrays {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *XMLMapping) value(e *xmlElement) interface{} {
+	text := e.text.String()
+	if len(e.attrs) == 0 && len(e.names) == 0 {
+		return this.text(text)
+	}
+	object := make(map[string]interface{})
+	for _, attr := range e.attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		object[this.AttributePrefix+attr.Name.Local] = this.text(attr.Value)
+	}
+	for _, name := range e.names {
+		children := e.children[name]
+		if len(children) == 1 && !this.isArray(name) {
+			object[name] = children[0]
+		} else {
+			object[name] = children
+		}
+	}
+	if trimmed := strings.TrimSpace(text); len(trimmed) > 0 {
+		object[this.TextKey] = this.text(trimmed)
+	}
+	return object
+}
+
+func (this *XMLMapping) text(s string) interface{} {
+	if !this.InferTypes {
+		return s
+	}
+	trimmed := strings.TrimSpace(s)
+	switch trimmed {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if isJsonNumber(trimmed) {
+		return json.Number(trimmed)
+	}
+	return s
+}
+
+//isJsonNumber reports whether s is a json number, which "+1", "Inf" and "0x1" are not.
+func isJsonNumber(s string) bool {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return false
+	}
+	_, ok := v.(json.Number)
+	return ok && !dec.More()
+}
@@ -0,0 +1,152 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+)
+
+const xmlOrder = `<?xml version="1.0"?>
+<order id="7" xmlns="http://example.com/order">
+	<customer>ann</customer>
+	<item sku="a">2</item>
+	<item sku="b">3</item>
+	<note/>
+</order>`
+
+func TestXMLMappingDecode(t *testing.T) {
+	got, err := DefaultXMLMapping.Decode([]byte(xmlOrder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := decodeInstance([]byte(`{
+		"@id": "7",
+		"customer": "ann",
+		"item": [{"@sku": "a", "#text": "2"}, {"@sku": "b", "#text": "3"}],
+		"note": ""
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalInstance(want, got) {
+		t.Fatalf("expected %#v, but got %#v", want, got)
+	}
+}
+
+func TestXMLMappingInferTypes(t *testing.T) {
+	m := &XMLMapping{AttributePrefix: "_", TextKey: "text", InferTypes: true, Arrays: []string{"tag"}}
+	got, err := m.Decode([]byte(`<a n="1.5"><b>true</b><c>+1</c><tag>x</tag></a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := decodeInstance([]byte(`{"_n": 1.5, "b": true, "c": "+1", "tag": ["x"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalInstance(want, got) {
+		t.Fatalf("expected %#v, but got %#v", want, got)
+	}
+}
+
+func TestXMLMappingDefaults(t *testing.T) {
+	m := &XMLMapping{InferTypes: true}
+	got, err := m.Decode([]byte(`<a n="1"><b/>c</a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := decodeInstance([]byte(`{"@n": 1, "b": "", "#text": "c"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalInstance(want, got) {
+		t.Fatalf("expected %#v, but got %#v", want, got)
+	}
+}
+
+//TestXMLParser checks that katydid's xml parser, with the mapping, presents the same json as the decoded document.
+func TestXMLParser(t *testing.T) {
+	mappings := []*XMLMapping{
+		DefaultXMLMapping,
+		{AttributePrefix: "_", TextKey: "text", InferTypes: true, Arrays: []string{"customer"}},
+	}
+	docs := []string{
+		xmlOrder,
+		`<a x="1"><b>1</b><c><d>true</d> tail </c><b>2</b><customer/></a>`,
+		`<a>text</a>`,
+	}
+	for _, mapping := range mappings {
+		for _, doc := range docs {
+			instance, err := mapping.Decode([]byte(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ip := newInstanceParser()
+			ip.Init(instance)
+			want, err := walk(ip, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			xp := newXMLParser()
+			if err := xp.Init([]byte(doc), mapping); err != nil {
+				t.Fatal(err)
+			}
+			got, err := walk(xp, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Fatalf("%s: expected %s, but got %s", doc, want, got)
+			}
+		}
+	}
+}
+
+func TestInstanceParser(t *testing.T) {
+	instance, err := decodeInstance([]byte(`{"b": [true, null, {"c": "d"}], "a": 1, "e": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newInstanceParser()
+	p.Init(instance)
+	got, err := walk(p, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{a:{1},b:{0:{true},1:{null},2:{c:{"d"}}},e:{}}`
+	if got != want {
+		t.Fatalf("expected %s, but got %s", want, got)
+	}
+}
+
+func TestValidateXML(t *testing.T) {
+	v, err := Compile([]byte(`{
+		"type": "object",
+		"properties": {
+			"@id": {"type": "string", "pattern": "^[0-9]+$"},
+			"customer": {"type": "string", "maxLength": 5}
+		},
+		"required": ["@id", "customer", "item"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.ValidateXML([]byte(xmlOrder), nil); err != nil {
+		t.Fatalf("expected valid, but got %v", err)
+	}
+	if err := v.ValidateXML([]byte(`<order id="x"><customer>ann</customer><item/></order>`), nil); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid, but got %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+//  Copyright 2015 Walter Schulze
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"github.com/katydid/katydid/serialize"
+	"github.com/katydid/katydid/serialize/xml"
+	"io"
+	"sort"
+	"strings"
+)
+
+//xmlFrame is a level in the json value that the xmlParser is presenting.
+type xmlFrame struct {
+	//leaf is set when the value is the string, or with InferTypes the json value, of a text or an attribute.
+	leaf *instanceParser
+	//err is returned by Next when the element could not be read.
+	err error
+	//path is the position of the element among its siblings at each level of the document.
+	path []int
+	//keys are the sorted property names of an object.
+	keys []string
+	//values are the texts of the attributes and the TextKey of an object.
+	values map[string]string
+	//positions are the positions of the child elements with the same name among the children of the element.
+	positions map[string][]int
+	//elements are the positions of the repeated elements, if the value is an array.
+	elements []int
+	isArray  bool
+	index    int
+}
+
+//xmlParser is a katydid serialize.Parser that presents an xml document as the json that an XMLMapping maps it to.
+//It reads the document with katydid's xml parser, where attributes are fields named with the AttributePrefix and
+//texts are fields named TextKey.
+//Repeated elements can only be recognized once all their siblings are read,
+//so every element is read by a new katydid xml parser that skips to the element's children.
+//Only the attributes and text of the elements that are being walked are kept in memory.
+type xmlParser struct {
+	mapping *XMLMapping
+	data    []byte
+	frames  []*xmlFrame
+}
+
+func newXMLParser() *xmlParser {
+	return &xmlParser{}
+}
+
+func (this *xmlParser) Init(data []byte, mapping *XMLMapping) error {
+	this.data = data
+	this.mapping = mapping
+	p, err := this.open(nil)
+	if err != nil {
+		return err
+	}
+	for pos := 0; ; pos++ {
+		if err := p.Next(); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("xml document has no root element")
+			}
+			return err
+		}
+		if p.IsLeaf() {
+			continue
+		}
+		root := this.element([]int{pos})
+		if root.err != nil {
+			return root.err
+		}
+		this.frames = append(this.frames[:0], root)
+		return nil
+	}
+}
+
+//open returns a katydid xml parser that is positioned before the children of the element at the path.
+func (this *xmlParser) open(path []int) (serialize.Parser, error) {
+	p := xml.NewXMLParser(xml.WithAttrPrefix(this.mapping.AttributePrefix), xml.WithTextName(this.mapping.TextKey))
+	if err := p.Init(this.data); err != nil {
+		return nil, err
+	}
+	for _, pos := range path {
+		for i := 0; i <= pos; i++ {
+			if err := p.Next(); err != nil {
+				return nil, err
+			}
+		}
+		p.Down()
+	}
+	return p, nil
+}
+
+//xmlText concatenates the leaves below the current field.
+func xmlText(p serialize.Parser) (string, error) {
+	p.Down()
+	defer p.Up()
+	s := ""
+	for {
+		if err := p.Next(); err != nil {
+			if err == io.EOF {
+				return s, nil
+			}
+			return "", err
+		}
+		if !p.IsLeaf() {
+			continue
+		}
+		t, err := p.String()
+		if err != nil {
+			return "", err
+		}
+		s += t
+	}
+}
+
+func (this *xmlParser) leaf(s string) *xmlFrame {
+	p := newInstanceParser()
+	p.Init(this.mapping.text(s))
+	return &xmlFrame{leaf: p}
+}
+
+//element reads the children of the element at the path, which maps to a string or an object.
+func (this *xmlParser) element(path []int) *xmlFrame {
+	p, err := this.open(path)
+	if err != nil {
+		return &xmlFrame{err: err}
+	}
+	f := &xmlFrame{
+		path:      path,
+		values:    make(map[string]string),
+		positions: make(map[string][]int),
+		index:     -1,
+	}
+	content := ""
+	for pos := 0; ; pos++ {
+		if err := p.Next(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return &xmlFrame{err: err}
+		}
+		if p.IsLeaf() {
+			s, err := p.String()
+			if err != nil {
+				return &xmlFrame{err: err}
+			}
+			content += s
+			continue
+		}
+		name, err := p.String()
+		if err != nil {
+			return &xmlFrame{err: err}
+		}
+		switch {
+		case name == this.mapping.TextKey:
+			s, err := xmlText(p)
+			if err != nil {
+				return &xmlFrame{err: err}
+			}
+			content += s
+		case strings.HasPrefix(name, this.mapping.AttributePrefix):
+			if name == this.mapping.AttributePrefix+"xmlns" {
+				continue
+			}
+			s, err := xmlText(p)
+			if err != nil {
+				return &xmlFrame{err: err}
+			}
+			f.keys = append(f.keys, name)
+			f.values[name] = s
+		default:
+			if _, ok := f.positions[name]; !ok {
+				f.keys = append(f.keys, name)
+			}
+			f.positions[name] = append(f.positions[name], pos)
+		}
+	}
+	if len(f.keys) == 0 {
+		return this.leaf(content)
+	}
+	if trimmed := strings.TrimSpace(content); len(trimmed) > 0 {
+		f.keys = append(f.keys, this.mapping.TextKey)
+		f.values[this.mapping.TextKey] = trimmed
+	}
+	sort.Strings(f.keys)
+	return f
+}
+
+func (this *xmlParser) top() *xmlFrame {
+	return this.frames[len(this.frames)-1]
+}
+
+func (this *xmlParser) Next() error {
+	f := this.top()
+	if f.err != nil {
+		return f.err
+	}
+	if f.leaf != nil {
+		return f.leaf.Next()
+	}
+	f.index++
+	n := len(f.keys)
+	if f.isArray {
+		n = len(f.elements)
+	}
+	if f.index >= n {
+		return io.EOF
+	}
+	return nil
+}
+
+func (this *xmlParser) IsLeaf() bool {
+	return this.top().leaf != nil
+}
+
+//childPath returns the path of a child element.
+func childPath(path []int, pos int) []int {
+	return append(append([]int{}, path...), pos)
+}
+
+func (this *xmlParser) Down() {
+	f := this.top()
+	var next *xmlFrame
+	if f.isArray {
+		next = this.element(childPath(f.path, f.elements[f.index]))
+	} else {
+		key := f.keys[f.index]
+		if s, ok := f.values[key]; ok {
+			next = this.leaf(s)
+		} else if ps := f.positions[key]; len(ps) == 1 && !this.mapping.isArray(key) {
+			next = this.element(childPath(f.path, ps[0]))
+		} else {
+			next = &xmlFrame{path: f.path, elements: ps, isArray: true, index: -1}
+		}
+	}
+	this.frames = append(this.frames, next)
+}
+
+func (this *xmlParser) Up() {
+	this.frames = this.frames[:len(this.frames)-1]
+}
+
+func (this *xmlParser) Double() (float64, error) {
+	f := this.top()
+	if f.leaf != nil {
+		return f.leaf.Double()
+	}
+	if f.isArray {
+		return float64(f.index), nil
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+func (this *xmlParser) Int() (int64, error) {
+	f := this.top()
+	if f.leaf != nil {
+		return f.leaf.Int()
+	}
+	if f.isArray {
+		return int64(f.index), nil
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+func (this *xmlParser) Uint() (uint64, error) {
+	f := this.top()
+	if f.leaf != nil {
+		return f.leaf.Uint()
+	}
+	if f.isArray {
+		return uint64(f.index), nil
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+func (this *xmlParser) Bool() (bool, error) {
+	f := this.top()
+	if f.leaf != nil {
+		return f.leaf.Bool()
+	}
+	return false, fmt.Errorf("not a bool")
+}
+
+func (this *xmlParser) String() (string, error) {
+	f := this.top()
+	if f.leaf != nil {
+		return f.leaf.String()
+	}
+	if f.isArray {
+		return "", fmt.Errorf("not a string")
+	}
+	return f.keys[f.index], nil
+}
+
+func (this *xmlParser) Bytes() ([]byte, error) {
+	return nil, fmt.Errorf("not bytes")
+}